@@ -0,0 +1,155 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rotatefile implements a size- and age-rotating io.WriteCloser
+// for processes that stream structured output to disk over a long
+// supervision run, such as recur's --report-mode stream sink. It follows
+// the autofile/logjack rotating-file idiom from the tmlibs ecosystem: the
+// file being rotated out is fsynced before it's renamed, so a process
+// killed right after rotation can't lose the tail that was written to it.
+package rotatefile
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer appends writes to the file at Path, rotating it to
+// "<Path>.<unix-timestamp-in-nanoseconds>" once the next write would push it past
+// MaxSize bytes, or once it's been open for MaxAge. A zero MaxSize or
+// MaxAge disables that trigger. The zero value isn't usable; use New.
+type Writer struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// New returns a Writer for path. maxSize and maxAge of zero disable the
+// corresponding rotation trigger.
+func New(path string, maxSize int64, maxAge time.Duration) *Writer {
+	return &Writer{
+		Path:    path,
+		MaxSize: maxSize,
+		MaxAge:  maxAge,
+		now:     time.Now,
+	}
+}
+
+// Write appends p to the current file, rotating first if p would cross
+// MaxSize or the file is older than MaxAge.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write to report file: %w", err)
+	}
+
+	return n, nil
+}
+
+// Close fsyncs and closes the current file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+
+		return fmt.Errorf("failed to sync report file: %w", err)
+	}
+
+	return w.file.Close()
+}
+
+func (w *Writer) shouldRotate(next int64) bool {
+	if w.MaxSize > 0 && w.size+next > w.MaxSize {
+		return true
+	}
+
+	if w.MaxAge > 0 && w.now().Sub(w.openedAt) >= w.MaxAge {
+		return true
+	}
+
+	return false
+}
+
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open report file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to stat report file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync report file before rotation: %w", err)
+	}
+
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close report file before rotation: %w", err)
+	}
+
+	rotated := fmt.Sprintf("%s.%d", w.Path, w.now().UnixNano())
+	if err := os.Rename(w.Path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate report file: %w", err)
+	}
+
+	return w.open()
+}
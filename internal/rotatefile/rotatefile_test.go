@@ -0,0 +1,105 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rotatefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriterRotatesOnSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.jsonl")
+
+	w := New(path, 10, 0)
+	defer w.Close()
+
+	if _, err := w.Write([]byte("12345678\n")); err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+
+	if _, err := w.Write([]byte("12345678\n")); err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+
+	entries, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatalf("glob failed: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the file to rotate once, got entries %v", entries)
+	}
+}
+
+func TestWriterRotatesOnAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.jsonl")
+
+	w := New(path, 0, time.Millisecond)
+
+	clock := time.Now()
+	w.now = func() time.Time { return clock }
+
+	defer w.Close()
+
+	if _, err := w.Write([]byte("a\n")); err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+
+	clock = clock.Add(time.Second)
+
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+
+	entries, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatalf("glob failed: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the file to rotate once, got entries %v", entries)
+	}
+}
+
+func TestWriterCloseSyncsBeforeClosing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.jsonl")
+
+	w := New(path, 0, 0)
+
+	if _, err := w.Write([]byte("hello\n")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report file: %v", err)
+	}
+
+	if string(data) != "hello\n" {
+		t.Errorf("expected %q, got %q", "hello\n", string(data))
+	}
+}
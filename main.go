@@ -24,14 +24,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"log"
-	"math"
-	"math/rand/v2"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -43,45 +39,26 @@ import (
 	"github.com/alecthomas/repr"
 	"github.com/mitchellh/go-wordwrap"
 	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dbohdan/recur/v2/internal/rotatefile"
+	"github.com/dbohdan/recur/v2/pkg/recur"
 )
 
 const (
-	envVarAttempt           = "RECUR_ATTEMPT"
-	envVarAttemptSinceReset = "RECUR_ATTEMPT_SINCE_RESET"
-	envVarMaxAttempts       = "RECUR_MAX_ATTEMPTS"
-	exitCodeBadUsage        = 2
-	exitCodeCommandNotFound = 127
-	exitCodeError           = 255
-	exitCodeTimeout         = 124
-	version                 = "3.1.0"
-
-	invSqrt5 = 0.4472135954999579
+	exitCodeBadUsage = 2
+	exitCodeError    = 255
+	version          = "3.1.0"
 
 	reportSecondsFormat = "%0.3f"
 	reportPadding       = 2
 
-	verboseLevelAttemptResults   = 1
 	verboseLevelConditionDetails = 2
 	verboseLevelConfigDebug      = 3
 	verboseLevelMax              = 3
 )
 
-type attempt struct {
-	CommandFound     bool
-	Duration         time.Duration
-	ExitCode         int
-	MaxAttempts      int
-	Number           int
-	NumberSinceReset int
-	TotalTime        time.Duration
-}
-
-type interval struct {
-	Start time.Duration
-	End   time.Duration
-}
-
-func parseInterval(s string) (interval, error) {
+func parseInterval(s string) (recur.Interval, error) {
 	var start, end time.Duration
 	var err error
 
@@ -91,43 +68,155 @@ func parseInterval(s string) (interval, error) {
 	case 2:
 		start, err = time.ParseDuration(strings.TrimRight(parts[0], " "))
 		if err != nil {
-			return interval{}, fmt.Errorf("invalid start duration: %s", parts[0])
+			return recur.Interval{}, fmt.Errorf("invalid start duration: %s", parts[0])
 		}
 
 		end, err = time.ParseDuration(strings.TrimLeft(parts[1], " "))
 		if err != nil {
-			return interval{}, fmt.Errorf("invalid end duration: %s", parts[1])
+			return recur.Interval{}, fmt.Errorf("invalid end duration: %s", parts[1])
 		}
 	case 1:
 		end, err = time.ParseDuration(parts[0])
 		if err != nil {
-			return interval{}, fmt.Errorf("invalid end duration: %s", parts[0])
+			return recur.Interval{}, fmt.Errorf("invalid end duration: %s", parts[0])
 		}
 
 		start = 0
 	default:
-		return interval{}, fmt.Errorf("invalid interval format: %s", s)
+		return recur.Interval{}, fmt.Errorf("invalid interval format: %s", s)
 	}
 
 	if start < 0 || end < 0 || start > end {
-		return interval{}, fmt.Errorf("invalid interval values: start=%s, end=%s", start.String(), end.String())
+		return recur.Interval{}, fmt.Errorf("invalid interval values: start=%s, end=%s", start.String(), end.String())
 	}
 
-	return interval{Start: start, End: end}, nil
+	return recur.Interval{Start: start, End: end}, nil
 }
 
-type commandStatus int
+// parseStrategySpec parses one -S/--strategy value, "name" or
+// "name:arg,arg", into a recur.DelayStrategy.
+func parseStrategySpec(spec string) (recur.DelayStrategy, error) {
+	name, rest, _ := strings.Cut(spec, ":")
 
-const (
-	statusFinished commandStatus = iota
-	statusTimeout
-	statusNotFound
-	statusUnknownError
-)
+	var args []string
+	if rest != "" {
+		args = strings.Split(rest, ",")
+	}
+
+	arg := func(i int) (time.Duration, error) {
+		if i >= len(args) {
+			return 0, fmt.Errorf("strategy %q requires an argument at position %d", name, i+1)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(args[i]))
+		if err != nil {
+			return 0, fmt.Errorf("strategy %q: invalid duration: %s", name, args[i])
+		}
+
+		return d, nil
+	}
+
+	switch name {
+	case "constant":
+		d, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+
+		return recur.NewConstantStrategy(d), nil
+
+	case "exponential", "exp":
+		d, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+
+		return recur.NewExponentialStrategy(d), nil
+
+	case "fibonacci", "fib":
+		return recur.NewFibonacciStrategy(), nil
+
+	case "linear":
+		d, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+
+		return recur.NewLinearStrategy(d), nil
+
+	case "uniform-jitter", "jitter":
+		interval, err := parseInterval(rest)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %q: %w", name, err)
+		}
+
+		return recur.NewUniformJitterStrategy(interval), nil
+
+	case "cap":
+		d, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+
+		return recur.NewCapStrategy(d), nil
+
+	case "decorrelated":
+		base, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+
+		capDelay, err := arg(1)
+		if err != nil {
+			return nil, err
+		}
+
+		return recur.NewDecorrelatedStrategy(base, capDelay), nil
+
+	default:
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+}
+
+// parseBreakerSpec parses one --breaker value, "N/DURATION", into the
+// failure count and rolling window for Config.BreakerMaxFailures and
+// Config.BreakerWindow.
+func parseBreakerSpec(spec string) (int, time.Duration, error) {
+	countStr, windowStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf(`invalid breaker spec %q: want "N/DURATION"`, spec)
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid breaker failure count: %s", countStr)
+	}
 
-type commandResult struct {
-	Status   commandStatus
-	ExitCode int
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid breaker window: %s", windowStr)
+	}
+
+	return count, window, nil
+}
+
+// legacyDelayStrategies rebuilds the constant + exponential (+ optional
+// Fibonacci) + cap + jitter chain that -b/-F/-d/-m/-j have always implied,
+// for when no -S/--strategy is given.
+func legacyDelayStrategies(config retryConfig) []recur.DelayStrategy {
+	strategies := []recur.DelayStrategy{
+		recur.NewConstantStrategy(config.FixedDelay.Start),
+		recur.NewExponentialStrategy(config.Backoff),
+	}
+
+	if config.Fibonacci {
+		strategies = append(strategies, recur.NewFibonacciStrategy())
+	}
+
+	return append(strategies,
+		recur.NewCapStrategy(config.FixedDelay.End),
+		recur.NewUniformJitterStrategy(config.RandomDelay),
+	)
 }
 
 type reportFormat int
@@ -136,6 +225,8 @@ const (
 	reportFormatNone reportFormat = iota
 	reportFormatJSON
 	reportFormatText
+	reportFormatYAML
+	reportFormatNDJSON
 )
 
 func (r reportFormat) String() string {
@@ -149,6 +240,12 @@ func (r reportFormat) String() string {
 	case reportFormatText:
 		return "text"
 
+	case reportFormatYAML:
+		return "yaml"
+
+	case reportFormatNDJSON:
+		return "ndjson"
+
 	default:
 		return "unknown"
 	}
@@ -165,307 +262,367 @@ func parseReportFormat(s string) (reportFormat, error) {
 	case "text":
 		return reportFormatText, nil
 
+	case "yaml":
+		return reportFormatYAML, nil
+
+	case "ndjson":
+		return reportFormatNDJSON, nil
+
 	default:
 		return reportFormatNone, fmt.Errorf("invalid report format: %s", s)
 	}
 }
 
-type retryConfig struct {
-	Command     string
-	Args        []string
-	Backoff     time.Duration
-	Condition   string
-	Fibonacci   bool
-	FixedDelay  interval
-	HoldStderr  bool
-	HoldStdout  bool
-	MaxAttempts int
-	RandomDelay interval
-	RandomSeed  uint64
-	ReplayStdin bool
-	Report      reportFormat
-	ReportFile  string
-	Reset       time.Duration
-	Timeout     time.Duration
-	Verbose     int
-}
-
-type recurStats struct {
-	Attempts         int
-	CommandFound     []bool
-	ConditionResults []bool
-	ExitCodes        []int
-	Failures         int
-	Successes        int
-	TotalTime        time.Duration
-	WaitTimes        []time.Duration
-}
+// reportFormatByExtension guesses a report format from a file path when
+// -R/--report is given a bare path instead of a format name or
+// "format:path" spec.
+func reportFormatByExtension(path string) reportFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return reportFormatJSON
 
-const (
-	backoffDefault     = time.Duration(0)
-	conditionDefault   = "code == 0"
-	delayDefault       = time.Duration(0)
-	jitterDefault      = "0,0"
-	maxDelayDefault    = time.Hour
-	maxAttemptsDefault = 10
-	randomSeedDefault  = uint64(0)
-	reportDefault      = reportFormatNone
-	reportFileDefault  = "-"
-	resetDefault       = -time.Second
-	timeoutDefault     = -time.Second
-)
+	case ".yaml", ".yml":
+		return reportFormatYAML
 
-type elapsedTimeWriter struct {
-	startTime time.Time
+	case ".ndjson":
+		return reportFormatNDJSON
+
+	default:
+		return reportFormatText
+	}
 }
 
-//nolint:mnd
-func (w *elapsedTimeWriter) Write(bytes []byte) (int, error) {
-	elapsed := time.Since(w.startTime)
+// parseReportSpec parses the value of -R/--report, which can be a bare
+// format name ("none", "json", "text", "yaml", "ndjson"), a "format:path" spec that
+// pairs a format with an output path (e.g. "yaml:report.yml", "json:-"),
+// or a bare path whose format is guessed from its extension. currentFile
+// is the report file to keep when the spec doesn't name one of its own.
+func parseReportSpec(s string, currentFile string) (reportFormat, string, error) {
+	if s == "" {
+		return reportFormatNone, currentFile, nil
+	}
 
-	hours := int(elapsed.Hours())
-	minutes := int(elapsed.Minutes()) % 60
-	seconds := int(elapsed.Seconds()) % 60
-	deciseconds := elapsed.Milliseconds() % 1000 / 100
+	if format, err := parseReportFormat(s); err == nil {
+		return format, currentFile, nil
+	}
 
-	//nolint:wrapcheck
-	return fmt.Fprintf(os.Stderr, "recur [%02d:%02d:%02d.%01d]: %s", hours, minutes, seconds, deciseconds, string(bytes))
-}
+	if prefix, file, ok := strings.Cut(s, ":"); ok {
+		format, err := parseReportFormat(prefix)
+		if err != nil {
+			return reportFormatNone, "", fmt.Errorf("invalid report format: %s", prefix)
+		}
 
-type exitRequestError struct {
-	Code int
-}
+		return format, file, nil
+	}
 
-func (e *exitRequestError) Error() string {
-	return fmt.Sprintf("exit requested with code %d", e.Code)
+	return reportFormatByExtension(s), s, nil
 }
 
-func executeCommand(command string, args []string, timeout time.Duration, envVars []string, stdinContent []byte, holdStdout bool, holdStderr bool) (commandResult, []byte, []byte) {
-	if _, err := exec.LookPath(command); err != nil {
-		return commandResult{
-			Status:   statusNotFound,
-			ExitCode: exitCodeCommandNotFound,
-		}, nil, nil
-	}
-
-	ctx := context.Background()
+type eventsFormat int
 
-	if timeout >= 0 {
-		var cancel context.CancelFunc
+const (
+	eventsFormatNone eventsFormat = iota
+	eventsFormatJSONL
+)
 
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
-	}
+func (e eventsFormat) String() string {
+	switch e {
+	case eventsFormatNone:
+		return "none"
 
-	cmd := exec.CommandContext(ctx, command, args...)
-	var stdoutBuffer, stderrBuffer bytes.Buffer
+	case eventsFormatJSONL:
+		return "jsonl"
 
-	if holdStdout {
-		cmd.Stdout = &stdoutBuffer
-	} else {
-		cmd.Stdout = os.Stdout
+	default:
+		return "unknown"
 	}
+}
 
-	if holdStderr {
-		cmd.Stderr = &stderrBuffer
-	} else {
-		cmd.Stderr = os.Stderr
+// parseEventsSpec parses the value of --events, a "format:target" spec
+// using the same format-prefix convention as -R/--report (see
+// parseReportSpec). The format is "jsonl" or its synonym "ndjson"; the
+// target is "-" for stdout, "fd:<n>" for an inherited file descriptor, or
+// a file path.
+func parseEventsSpec(s string) (eventsFormat, string, error) {
+	prefix, target, ok := strings.Cut(s, ":")
+	if !ok {
+		return eventsFormatNone, "", fmt.Errorf("invalid events spec, want \"jsonl:<target>\": %s", s)
 	}
 
-	if stdinContent == nil {
-		cmd.Stdin = os.Stdin
-	} else {
-		cmd.Stdin = bytes.NewReader(stdinContent)
+	switch prefix {
+	case "jsonl", "ndjson":
+		return eventsFormatJSONL, target, nil
+
+	default:
+		return eventsFormatNone, "", fmt.Errorf("invalid events format: %s", prefix)
 	}
+}
 
-	cmd.Env = append(os.Environ(), envVars...)
+// openEventsSink opens the target named by a parsed --events spec: "-"
+// for stdout, "fd:<n>" for an inherited file descriptor, or a path to
+// create.
+func openEventsSink(target string) (io.WriteCloser, error) {
+	if target == "-" {
+		// Stdout, not stderr: recur's own diagnostics (the summary line,
+		// usage errors) go to stderr, and interleaving them here would
+		// break a consumer parsing the stream as JSON Lines.
+		return nopWriteCloser{os.Stdout}, nil
+	}
 
-	err := cmd.Run()
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return commandResult{
-				Status:   statusTimeout,
-				ExitCode: exitCodeTimeout,
-			}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+	if fdStr, ok := strings.CutPrefix(target, "fd:"); ok {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file descriptor: %s", fdStr)
 		}
 
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return commandResult{
-				Status:   statusFinished,
-				ExitCode: exitErr.ExitCode(),
-			}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
-		}
+		//nolint:gosec
+		return os.NewFile(uintptr(fd), fmt.Sprintf("fd/%d", fd)), nil
+	}
 
-		return commandResult{
-			Status:   statusUnknownError,
-			ExitCode: exitCodeError,
-		}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+	file, err := os.Create(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events file: %w", err)
 	}
 
-	return commandResult{
-		Status:   statusFinished,
-		ExitCode: cmd.ProcessState.ExitCode(),
-	}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+	return file, nil
 }
 
-func fib(n int) float64 {
-	nf := float64(n)
+type reportMode int
 
-	return math.Round((math.Pow(math.Phi, nf) - math.Pow(-math.Phi, -nf)) * invSqrt5)
-}
+const (
+	reportModeFinal reportMode = iota
+	reportModeStream
+)
 
-func delayBeforeAttempt(attemptNum int, config retryConfig, rng *rand.Rand) time.Duration {
-	if attemptNum == 1 {
-		return 0
-	}
+func (r reportMode) String() string {
+	switch r {
+	case reportModeFinal:
+		return "final"
 
-	currFixed := config.FixedDelay.Start.Seconds()
-	currFixed += math.Pow(config.Backoff.Seconds(), float64(attemptNum-1))
+	case reportModeStream:
+		return "stream"
 
-	if config.Fibonacci {
-		currFixed += fib(attemptNum - 1)
+	default:
+		return "unknown"
 	}
+}
 
-	if currFixed > config.FixedDelay.End.Seconds() {
-		currFixed = config.FixedDelay.End.Seconds()
-	}
+func parseReportMode(s string) (reportMode, error) {
+	switch s {
+	case "final":
+		return reportModeFinal, nil
 
-	currRandom := config.RandomDelay.Start.Seconds() +
-		rng.Float64()*(config.RandomDelay.End-config.RandomDelay.Start).Seconds()
+	case "stream":
+		return reportModeStream, nil
 
-	return time.Duration((currFixed + currRandom) * float64(time.Second))
+	default:
+		return reportModeFinal, fmt.Errorf("invalid report mode: %s", s)
+	}
 }
 
-func formatDuration(d time.Duration) string {
-	d = d.Round(time.Millisecond)
-	if d > time.Second {
-		//nolint:mnd
-		d = d.Round(100 * time.Millisecond)
-	}
+// sizeUnits maps the binary byte suffixes --report-rotate-size accepts to
+// their multiplier, longest suffix first so e.g. "KiB" is tried before "B".
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
 
-	zeroUnits := regexp.MustCompile("(^|[^0-9])(?:0h)?(?:0m)?(?:0s)?$")
-	s := zeroUnits.ReplaceAllString(d.String(), "$1")
+// parseSize parses a byte count like "10MiB", "512KiB", or "1024" (bytes).
+func parseSize(s string) (int64, error) {
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) && len(s) > len(unit.suffix) {
+			rest := s[:len(s)-len(unit.suffix)]
 
-	if s == "" {
-		return "0"
-	}
+			n, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size: %s", s)
+			}
 
-	return s
-}
+			return n * unit.multiplier, nil
+		}
+	}
 
-func retry(config retryConfig, stdinContent []byte, rng *rand.Rand) (int, recurStats, error) {
-	var stats recurStats
-	var cmdResult commandResult
-	var stdoutContent, stderrContent []byte
-	var startTime time.Time
-	var totalTime time.Duration
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
 
-	stats.ExitCodes = make([]int, 0)
-	stats.WaitTimes = make([]time.Duration, 0)
-	stats.CommandFound = make([]bool, 0)
-	stats.ConditionResults = make([]bool, 0)
+	return n, nil
+}
 
-	resetAttemptNum := 1
-	for attemptNum := 1; config.MaxAttempts < 0 || attemptNum <= config.MaxAttempts; attemptNum++ {
-		attemptSinceReset := attemptNum - resetAttemptNum + 1
-		delay := delayBeforeAttempt(attemptSinceReset, config, rng)
+type retryConfig struct {
+	Command            string
+	Args               []string
+	Backoff            time.Duration
+	BreakerConsecutive int
+	BreakerMaxFailures int
+	BreakerWindow      time.Duration
+	Condition          string
+	ConditionFile      string
+	EventsFormat       eventsFormat
+	EventsTarget       string
+	Fibonacci          bool
+	FixedDelay         recur.Interval
+	HoldStderr         bool
+	HoldStdout         bool
+	MaxAttempts        int
+	RandomDelay        recur.Interval
+	RandomSeed         uint64
+	ReplayStdin        bool
+	Report             reportFormat
+	ReportFile         string
+	ReportMode         reportMode
+	ReportRotateAge    time.Duration
+	ReportRotateSize   int64
+	Reset              time.Duration
+	StarlarkModules    []string
+	Strategies         []recur.DelayStrategy
+	Timeout            time.Duration
+	Verbose            int
+}
 
-		stats.WaitTimes = append(stats.WaitTimes, delay)
+const (
+	backoffDefault     = time.Duration(0)
+	conditionDefault   = "code == 0"
+	delayDefault       = time.Duration(0)
+	jitterDefault      = "0,0"
+	maxDelayDefault    = time.Hour
+	maxAttemptsDefault = 10
+	randomSeedDefault  = uint64(0)
+	reportDefault      = reportFormatNone
+	reportFileDefault  = "-"
+	reportModeDefault  = reportModeFinal
+	resetDefault       = -time.Second
+	timeoutDefault     = -time.Second
+)
 
-		if delay > 0 {
-			if config.Verbose >= verboseLevelAttemptResults {
-				log.Printf("waiting %s after attempt %d", formatDuration(delay), attemptNum-1)
-			}
+// fileConfig holds the subset of retryConfig that --config can set from a
+// YAML or JSON file. Fields are pointers so that an absent key leaves the
+// corresponding retryConfig field untouched.
+type fileConfig struct {
+	Attempts   *int    `json:"attempts" yaml:"attempts"`
+	Backoff    *string `json:"backoff" yaml:"backoff"`
+	Jitter     *string `json:"jitter" yaml:"jitter"`
+	Condition  *string `json:"condition" yaml:"condition"`
+	Timeout    *string `json:"timeout" yaml:"timeout"`
+	Reset      *string `json:"reset" yaml:"reset"`
+	Report     *string `json:"report" yaml:"report"`
+	Verbose    *int    `json:"verbose" yaml:"verbose"`
+	HoldStdout *bool   `json:"hold_stdout" yaml:"hold_stdout"`
+	HoldStderr *bool   `json:"hold_stderr" yaml:"hold_stderr"`
+}
 
-			time.Sleep(delay)
-		}
+// applyConfigFile loads a YAML or JSON --config file (format guessed from
+// its extension, defaulting to YAML) and copies any keys it sets into
+// config. It's called before the command-line options are parsed, so a
+// later CLI flag always overrides the value it loads.
+func applyConfigFile(config *retryConfig, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
 
-		attemptStart := time.Now()
-		if startTime.IsZero() {
-			startTime = attemptStart
-		}
+	var fc fileConfig
 
-		envVars := []string{
-			fmt.Sprintf("%s=%d", envVarAttempt, attemptNum),
-			fmt.Sprintf("%s=%d", envVarAttemptSinceReset, attemptSinceReset),
-			fmt.Sprintf("%s=%d", envVarMaxAttempts, config.MaxAttempts),
-		}
-		cmdResult, stdoutContent, stderrContent = executeCommand(config.Command, config.Args, config.Timeout, envVars, stdinContent, config.HoldStdout, config.HoldStderr)
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &fc)
+	} else {
+		err = yaml.Unmarshal(data, &fc)
+	}
 
-		attemptEnd := time.Now()
-		attemptDuration := attemptEnd.Sub(attemptStart)
-		totalTime = attemptEnd.Sub(startTime)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
 
-		stats.ExitCodes = append(stats.ExitCodes, cmdResult.ExitCode)
-		stats.CommandFound = append(stats.CommandFound, cmdResult.Status != statusNotFound)
+	if fc.Attempts != nil {
+		config.MaxAttempts = *fc.Attempts
+	}
 
-		if config.Reset >= 0 && attemptDuration >= config.Reset {
-			resetAttemptNum = attemptNum
+	if fc.Backoff != nil {
+		backoff, err := time.ParseDuration(*fc.Backoff)
+		if err != nil {
+			return fmt.Errorf("invalid backoff: %w", err)
 		}
 
-		if config.Verbose >= verboseLevelAttemptResults {
-			switch cmdResult.Status {
-			case statusFinished:
-				log.Printf("command exited with code %d on attempt %d", cmdResult.ExitCode, attemptNum)
-			case statusTimeout:
-				log.Printf("command timed out after %s on attempt %d", formatDuration(attemptDuration), attemptNum)
-			case statusNotFound:
-				log.Printf("command was not found on attempt %d", attemptNum)
-			case statusUnknownError:
-				log.Printf("unknown error occurred on attempt %d", attemptNum)
-			}
-		}
+		config.Backoff = backoff
+	}
 
-		attemptInfo := attempt{
-			CommandFound:     cmdResult.Status != statusNotFound,
-			Duration:         attemptDuration,
-			ExitCode:         cmdResult.ExitCode,
-			MaxAttempts:      config.MaxAttempts,
-			Number:           attemptNum,
-			NumberSinceReset: attemptSinceReset,
-			TotalTime:        totalTime,
+	if fc.Jitter != nil {
+		jitter, err := parseInterval(*fc.Jitter)
+		if err != nil {
+			return fmt.Errorf("invalid jitter: %w", err)
 		}
 
-		evalResult, err := evaluateCondition(attemptInfo, config.Condition, stdinContent, stdoutContent, stderrContent, config.ReplayStdin, config.HoldStdout, config.HoldStderr)
+		config.RandomDelay = jitter
+	}
 
-		if evalResult.FlushStdout {
-			os.Stdout.Write(stdoutContent)
-		}
+	if fc.Condition != nil {
+		config.Condition = *fc.Condition
+	}
 
-		if evalResult.FlushStderr {
-			os.Stderr.Write(stderrContent)
+	if fc.Timeout != nil {
+		timeout, err := time.ParseDuration(*fc.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
 		}
 
-		stats.Attempts = attemptNum
+		config.Timeout = timeout
+	}
 
+	if fc.Reset != nil {
+		reset, err := time.ParseDuration(*fc.Reset)
 		if err != nil {
-			var exitErr *exitRequestError
-			if errors.As(err, &exitErr) {
-				return exitErr.Code, stats, nil
-			}
-
-			return 1, stats, fmt.Errorf("condition evaluation failed: %w", err)
+			return fmt.Errorf("invalid reset time: %w", err)
 		}
 
-		stats.ConditionResults = append(stats.ConditionResults, evalResult.Success)
-
-		if evalResult.Success {
-			stats.Successes++
+		config.Reset = reset
+	}
 
-			return cmdResult.ExitCode, stats, nil
+	if fc.Report != nil {
+		reportFormat, reportFile, err := parseReportSpec(*fc.Report, config.ReportFile)
+		if err != nil {
+			return fmt.Errorf("invalid report format: %w", err)
 		}
 
-		stats.Failures++
+		config.Report = reportFormat
+		config.ReportFile = reportFile
+	}
 
-		if config.Verbose >= verboseLevelConditionDetails {
-			log.Printf("condition not met; continuing to next attempt")
-		}
+	if fc.Verbose != nil {
+		config.Verbose = *fc.Verbose
+	}
+
+	if fc.HoldStdout != nil {
+		config.HoldStdout = *fc.HoldStdout
+	}
+
+	if fc.HoldStderr != nil {
+		config.HoldStderr = *fc.HoldStderr
 	}
 
-	stats.TotalTime = totalTime
+	return nil
+}
+
+type elapsedTimeWriter struct {
+	startTime time.Time
+}
+
+//nolint:mnd
+func (w *elapsedTimeWriter) Write(bytes []byte) (int, error) {
+	elapsed := time.Since(w.startTime)
+
+	hours := int(elapsed.Hours())
+	minutes := int(elapsed.Minutes()) % 60
+	seconds := int(elapsed.Seconds()) % 60
+	deciseconds := elapsed.Milliseconds() % 1000 / 100
 
-	return cmdResult.ExitCode, stats, fmt.Errorf("maximum %d attempts reached", config.MaxAttempts)
+	//nolint:wrapcheck
+	return fmt.Fprintf(os.Stderr, "recur [%02d:%02d:%02d.%01d]: %s", hours, minutes, seconds, deciseconds, string(bytes))
 }
 
 func wrapForTerm(s string) string {
@@ -480,7 +637,7 @@ func wrapForTerm(s string) string {
 
 func usage(w io.Writer) {
 	s := fmt.Sprintf(
-		`Usage: %s [-h] [-V] [-a <attempts>] [-b <backoff>] [-c <condition>] [-d <delay>] [-E] [-F] [-f] [-I] [-j <jitter>] [-m <max-delay>] [-O] [-R <format>] [--report-file <path>] [-r <reset-time>] [-s <seed>] [-t <timeout>] [-v] [--] <command> [<arg> ...]`,
+		`Usage: %s [-h] [-V] [-a <attempts>] [-b <backoff>] [--breaker <n/duration>] [--breaker-consecutive <n>] [-c <condition>] [--condition-file <path>] [--config <path>] [-d <delay>] [-E] [--events <spec>] [-F] [-f] [-I] [-j <jitter>] [-m <max-delay>] [-O] [--print-report-schema] [-R <format>] [--report-file <path>] [--report-mode <mode>] [--report-rotate-size <size>] [--report-rotate-interval <duration>] [-r <reset-time>] [-S <strategy>] [-s <seed>] [--starlark-module <path>] [-t <timeout>] [-v] [--] <command> [<arg> ...]`,
 		filepath.Base(os.Args[0]),
 	)
 
@@ -494,6 +651,13 @@ func help() {
 		`
 Retry a command with exponential backoff and jitter.
 
+Commands:
+  repl [--fixture <path>]
+          Start an interactive Starlark session with the condition globals
+
+  verify-release <tarball> [--sums <path>] [--sig <path>]
+          Verify a downloaded release tarball's checksum and signature
+
 Arguments:
   <command>
           Command to run
@@ -514,12 +678,28 @@ Options:
   -b, --backoff %v
           Base for exponential backoff (duration)
 
+      --breaker <n/duration>
+          Abort if more than <n> attempts fail within a rolling <duration> window (e.g. "5/1m")
+
+      --breaker-consecutive <n>
+          Abort after <n> back-to-back failures, regardless of --breaker
+
   -c, --condition %q
           Success condition (Starlark expression)
 
+      --condition-file <path>
+          Success condition loaded from a Starlark file defining should_retry(ctx)
+
+      --config <path>
+          Load default option values from a YAML or JSON file (CLI flags take precedence)
+
   -d, --delay %v
           Constant delay (duration)
 
+      --events <spec>
+          Stream one JSON Lines event per attempt to a side channel as it completes:
+          "jsonl:<path>", "jsonl:-" for stdout, or "jsonl:fd:<n>" for an inherited file descriptor
+
   -E, --hold-stderr
           Buffer standard error for each attempt and only print it on success
 
@@ -541,18 +721,45 @@ Options:
   -O, --hold-stdout
           Buffer standard output for each attempt and only print it on success
 
+      --print-report-schema
+          Print the JSON Schema for the "json" report format and exit
+
   -R, --report %q
-          Report format ("none", "json", or "text")
+          Report format ("none", "json", "text", "yaml", or "ndjson"); also
+          accepts "<format>:<path>" (e.g. "yaml:report.yml") or a bare path
+          whose format is guessed from its extension. "ndjson" always
+          streams one line per attempt, followed by a final summary line,
+          regardless of --report-mode
 
       --report-file %q
           Report output file path ("-" for stderr)
 
+      --report-mode %q
+          Report mode: "final" (write once at the end) or "stream" (append a
+          JSON Lines entry per attempt as it completes)
+
+      --report-rotate-size <size>
+          Rotate the report file once it would exceed <size> (e.g. "10MiB"); streamed reports
+          (--report-mode stream or "ndjson") only
+
+      --report-rotate-interval <duration>
+          Rotate the report file once it's older than <duration>; streamed reports
+          (--report-mode stream or "ndjson") only
+
   -r, --reset %v
           Minimum attempt time that resets exponential and Fibonacci backoff (duration; negative for no reset)
 
+  -S, --strategy <name[:arg,...]>
+          Delay strategy to append to the chain (repeatable; overrides -b/-F/-d/-m/-j):
+          constant:<delay>, exponential:<base>, fibonacci, linear:<step>,
+          uniform-jitter:<min,max>, cap:<max>, decorrelated:<base,cap>
+
   -s, --seed %v
           Random seed for jitter (0 for automatic)
 
+      --starlark-module <path>
+          Directory to search for ".star" files loaded from a condition (repeatable)
+
   -t, --timeout %v
           Timeout for each attempt (duration; negative for no timeout)
 
@@ -560,16 +767,17 @@ Options:
           Increase verbosity (up to %v times)
 `,
 		maxAttemptsDefault,
-		formatDuration(backoffDefault),
+		recur.FormatDuration(backoffDefault),
 		conditionDefault,
-		formatDuration(delayDefault),
+		recur.FormatDuration(delayDefault),
 		jitterDefault,
-		formatDuration(maxDelayDefault),
+		recur.FormatDuration(maxDelayDefault),
 		reportDefault,
 		reportFileDefault,
-		formatDuration(resetDefault),
+		reportModeDefault,
+		recur.FormatDuration(resetDefault),
 		randomSeedDefault,
-		formatDuration(timeoutDefault),
+		recur.FormatDuration(timeoutDefault),
 		verboseLevelMax,
 	)
 
@@ -583,11 +791,11 @@ func parseArgs() retryConfig {
 		Command:     "",
 		Condition:   conditionDefault,
 		Fibonacci:   false,
-		FixedDelay:  interval{Start: delayDefault, End: maxDelayDefault},
+		FixedDelay:  recur.Interval{Start: delayDefault, End: maxDelayDefault},
 		HoldStderr:  false,
 		HoldStdout:  false,
 		MaxAttempts: maxAttemptsDefault,
-		RandomDelay: interval{Start: 0, End: 0},
+		RandomDelay: recur.Interval{Start: 0, End: 0},
 		RandomSeed:  randomSeedDefault,
 		ReplayStdin: false,
 		Reset:       resetDefault,
@@ -595,6 +803,9 @@ func parseArgs() retryConfig {
 		Verbose:     0,
 		Report:      reportFormatNone,
 		ReportFile:  reportFileDefault,
+		ReportMode:  reportModeDefault,
+
+		StarlarkModules: []string{},
 	}
 
 	usageError := func(message string, badValue any) {
@@ -608,6 +819,7 @@ func parseArgs() retryConfig {
 	// Parse the command-line options.
 	var i int
 	printHelp := false
+	printReportSchema := false
 	printVersion := false
 
 	nextArg := func(flag string) string {
@@ -620,6 +832,18 @@ func parseArgs() retryConfig {
 		return os.Args[i]
 	}
 
+	// --config is applied before the option loop below so that any CLI
+	// flag that follows it overrides the value loaded from the file.
+	for j := 1; j < len(os.Args)-1; j++ {
+		if os.Args[j] == "--config" {
+			if err := applyConfigFile(&config, os.Args[j+1]); err != nil {
+				usageError("invalid config file: %v", err)
+			}
+
+			break
+		}
+	}
+
 	for i = 1; i < len(os.Args); i++ {
 		arg := os.Args[i]
 
@@ -654,9 +878,37 @@ func parseArgs() retryConfig {
 
 			config.Backoff = backoff
 
+		case "--breaker":
+			value := nextArg(arg)
+
+			count, window, err := parseBreakerSpec(value)
+			if err != nil {
+				usageError("%v", err)
+			}
+
+			config.BreakerMaxFailures = count
+			config.BreakerWindow = window
+
+		case "--breaker-consecutive":
+			value := nextArg(arg)
+
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				usageError("invalid breaker consecutive-failure count: %v", value)
+			}
+
+			config.BreakerConsecutive = count
+
 		case "-c", "--condition":
 			config.Condition = nextArg(arg)
 
+		case "--condition-file":
+			config.ConditionFile = nextArg(arg)
+
+		case "--config":
+			// Already applied in the pre-scan above; consume the value.
+			nextArg(arg)
+
 		case "-d", "--delay":
 			value := nextArg(arg)
 
@@ -670,6 +922,17 @@ func parseArgs() retryConfig {
 				config.FixedDelay.End = config.FixedDelay.Start
 			}
 
+		case "--events":
+			value := nextArg(arg)
+
+			eventsFormat, eventsTarget, err := parseEventsSpec(value)
+			if err != nil {
+				usageError("invalid events spec: %v", err)
+			}
+
+			config.EventsFormat = eventsFormat
+			config.EventsTarget = eventsTarget
+
 		case "-F", "--fib":
 			config.Fibonacci = true
 
@@ -706,6 +969,9 @@ func parseArgs() retryConfig {
 		case "-E", "--hold-stderr":
 			config.HoldStderr = true
 
+		case "--print-report-schema":
+			printReportSchema = true
+
 		case "-r", "--reset":
 			value := nextArg(arg)
 
@@ -716,6 +982,16 @@ func parseArgs() retryConfig {
 
 			config.Reset = reset
 
+		case "-S", "--strategy":
+			value := nextArg(arg)
+
+			strategy, err := parseStrategySpec(value)
+			if err != nil {
+				usageError("invalid strategy: %v", err)
+			}
+
+			config.Strategies = append(config.Strategies, strategy)
+
 		case "-s", "--seed":
 			value := nextArg(arg)
 
@@ -736,19 +1012,53 @@ func parseArgs() retryConfig {
 
 			config.Timeout = timeout
 
+		case "--starlark-module":
+			config.StarlarkModules = append(config.StarlarkModules, nextArg(arg))
+
 		case "-R", "--report":
 			reportStr := nextArg(arg)
 
-			reportFormat, err := parseReportFormat(reportStr)
+			reportFormat, reportFile, err := parseReportSpec(reportStr, config.ReportFile)
 			if err != nil {
 				usageError("invalid report format: %v", reportStr)
 			}
 
 			config.Report = reportFormat
+			config.ReportFile = reportFile
 
 		case "--report-file":
 			config.ReportFile = nextArg(arg)
 
+		case "--report-mode":
+			modeStr := nextArg(arg)
+
+			mode, err := parseReportMode(modeStr)
+			if err != nil {
+				usageError("invalid report mode: %v", modeStr)
+			}
+
+			config.ReportMode = mode
+
+		case "--report-rotate-size":
+			value := nextArg(arg)
+
+			size, err := parseSize(value)
+			if err != nil {
+				usageError("invalid report rotation size: %v", value)
+			}
+
+			config.ReportRotateSize = size
+
+		case "--report-rotate-interval":
+			value := nextArg(arg)
+
+			interval, err := time.ParseDuration(value)
+			if err != nil {
+				usageError("invalid report rotation interval: %v", value)
+			}
+
+			config.ReportRotateAge = interval
+
 		// "-v" is handled in the default case.
 		case "--verbose":
 			config.Verbose++
@@ -777,6 +1087,11 @@ func parseArgs() retryConfig {
 		os.Exit(0)
 	}
 
+	if printReportSchema {
+		fmt.Print(reportSchemaJSON)
+		os.Exit(0)
+	}
+
 	if config.Verbose > verboseLevelMax {
 		usageError("up to %d verbose options is allowed", verboseLevelMax)
 	}
@@ -809,20 +1124,22 @@ func formatList[T any](list []T) string {
 	return strings.Join(strs, ", ")
 }
 
-func generateReport(stats recurStats, reportFormat reportFormat, reportFile string) {
+func generateReport(stats recur.Stats, reportFormat reportFormat, reportFile string) {
 	if reportFormat == reportFormatNone {
 		return
 	}
 
 	type reportData struct {
-		Attempts         int       `json:"attempts"`
-		CommandFound     []bool    `json:"command_found"`
-		ConditionResults []bool    `json:"condition_results"`
-		ExitCodes        []int     `json:"exit_codes"`
-		Failures         int       `json:"failures"`
-		Successes        int       `json:"successes"`
-		TotalTime        float64   `json:"total_time"`
-		WaitTimes        []float64 `json:"wait_times"`
+		Attempts         int       `json:"attempts" yaml:"attempts"`
+		BreakerTripped   bool      `json:"breaker_tripped" yaml:"breaker_tripped"`
+		BreakerTrippedAt int       `json:"breaker_tripped_at" yaml:"breaker_tripped_at"`
+		CommandFound     []bool    `json:"command_found" yaml:"command_found"`
+		ConditionResults []bool    `json:"condition_met" yaml:"condition_met"`
+		ExitCodes        []int     `json:"exit_codes" yaml:"exit_codes"`
+		Failures         int       `json:"failures" yaml:"failures"`
+		Successes        int       `json:"successes" yaml:"successes"`
+		TotalTime        float64   `json:"total_time" yaml:"total_time"`
+		WaitTimes        []float64 `json:"wait_times" yaml:"wait_times"`
 	}
 
 	waitTimeSeconds := make([]float64, len(stats.WaitTimes))
@@ -832,6 +1149,8 @@ func generateReport(stats recurStats, reportFormat reportFormat, reportFile stri
 
 	data := reportData{
 		Attempts:         stats.Attempts,
+		BreakerTripped:   stats.BreakerTripped,
+		BreakerTrippedAt: stats.BreakerTrippedAt,
 		CommandFound:     stats.CommandFound,
 		ConditionResults: stats.ConditionResults,
 		ExitCodes:        stats.ExitCodes,
@@ -841,20 +1160,8 @@ func generateReport(stats recurStats, reportFormat reportFormat, reportFile stri
 		WaitTimes:        waitTimeSeconds,
 	}
 
-	var output io.Writer
-	if reportFile == "-" {
-		output = os.Stderr
-	} else {
-		file, err := os.Create(reportFile)
-		if err != nil {
-			log.Printf("failed to create report file: %v", err)
-
-			return
-		}
-		defer file.Close()
-
-		output = file
-	}
+	var buf bytes.Buffer
+	output := &buf
 
 	switch reportFormat {
 	case reportFormatJSON:
@@ -875,6 +1182,16 @@ func generateReport(stats recurStats, reportFormat reportFormat, reportFile stri
 
 		fmt.Fprintf(output, "%s\n", string(jsonData))
 
+	case reportFormatYAML:
+		yamlData, err := yaml.Marshal(data)
+		if err != nil {
+			log.Printf("failed to marshal report to YAML: %v", err)
+
+			return
+		}
+
+		fmt.Fprintf(output, "%s", yamlData)
+
 	case reportFormatText:
 		tw := tabwriter.NewWriter(output, 0, 0, reportPadding, ' ', tabwriter.AlignRight)
 
@@ -883,12 +1200,16 @@ func generateReport(stats recurStats, reportFormat reportFormat, reportFile stri
 		fmt.Fprintf(tw, "Successes: \t%d\n", data.Successes)
 		fmt.Fprintf(tw, "Failures: \t%d\n", data.Failures)
 
+		if data.BreakerTripped {
+			fmt.Fprintf(tw, "Breaker tripped at attempt: \t%d\n", data.BreakerTrippedAt)
+		}
+
 		fmt.Fprintf(tw, "\t\n")
 		fmt.Fprintf(tw, "Total time: \t"+reportSecondsFormat+"\n", data.TotalTime)
 		fmt.Fprintf(tw, "Wait times: \t%s\n", formatList(data.WaitTimes))
 
 		fmt.Fprintf(tw, "\t\n")
-		fmt.Fprintf(tw, "Condition results: \t%s\n", formatList(data.ConditionResults))
+		fmt.Fprintf(tw, "Condition met: \t%s\n", formatList(data.ConditionResults))
 		fmt.Fprintf(tw, "Command found: \t%s\n", formatList(data.CommandFound))
 		fmt.Fprintf(tw, "Exit codes: \t%s\n", formatList(data.ExitCodes))
 
@@ -897,20 +1218,226 @@ func generateReport(stats recurStats, reportFormat reportFormat, reportFile stri
 	default:
 		panic("unreachable")
 	}
+
+	if reportFile == "-" {
+		os.Stderr.Write(buf.Bytes())
+
+		return
+	}
+
+	if err := writeFileAtomic(reportFile, buf.Bytes()); err != nil {
+		log.Printf("failed to write report file: %v", err)
+	}
+}
+
+// writeFileAtomic writes data to a temp file next to path (named like
+// "<path>.tmp-<pid>-<rand>") and renames it into place, so a reader can
+// never observe a partially written report. The temp file is removed if
+// any step fails.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf("%s.tmp-%d-*", filepath.Base(path), os.Getpid()))
+	if err != nil {
+		return fmt.Errorf("failed to create temp report file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to write temp report file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to close temp report file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to rename temp report file: %w", err)
+	}
+
+	return nil
+}
+
+// nopWriteCloser adapts an io.Writer that shouldn't be closed (os.Stdout,
+// os.Stderr) to io.WriteCloser, so the stream report and events sinks can
+// treat it the same as a file they open themselves.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// newReportStreamWriter opens the sink for a streamed report (--report-mode
+// stream or "-R ndjson"): a rotating file, or stderr when reportFile is "-".
+func newReportStreamWriter(reportFile string, rotateSize int64, rotateAge time.Duration) io.WriteCloser {
+	if reportFile == "-" {
+		return nopWriteCloser{os.Stderr}
+	}
+
+	return rotatefile.New(reportFile, rotateSize, rotateAge)
+}
+
+// attemptReport is one JSON Lines entry --report-mode stream writes per
+// attempt, as soon as that attempt's condition is evaluated.
+type attemptReport struct {
+	Attempt      int     `json:"attempt"`
+	CommandFound bool    `json:"command_found"`
+	ConditionMet bool    `json:"condition_met"`
+	ExitCode     int     `json:"exit_code"`
+	TotalTime    float64 `json:"total_time"`
+	Wait         float64 `json:"wait"`
+}
+
+func writeAttemptReport(w io.Writer, attempt recur.Attempt, success bool, wait time.Duration) {
+	data := attemptReport{
+		Attempt:      attempt.Number,
+		CommandFound: attempt.CommandFound,
+		ConditionMet: success,
+		ExitCode:     attempt.ExitCode,
+		TotalTime:    attempt.TotalTime.Seconds(),
+		Wait:         wait.Seconds(),
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to marshal attempt report to JSON: %v", err)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", jsonData)
+}
+
+// ndjsonAttempt is one JSON Lines entry "-R ndjson:" writes per attempt, as
+// soon as that attempt's condition is evaluated. Unlike attemptReport
+// (--report-mode stream) it's always line-buffered regardless of
+// --report-mode, and it carries a stderr snippet and the computed
+// next-delay so a "tail -f" reader doesn't need the final summary line to
+// follow along.
+type ndjsonAttempt struct {
+	Type          string  `json:"type"`
+	Attempt       int     `json:"attempt"`
+	StartedAt     string  `json:"started_at"`
+	EndedAt       string  `json:"ended_at"`
+	Duration      float64 `json:"duration"`
+	ExitCode      int     `json:"exit_code"`
+	StderrSnippet string  `json:"stderr_snippet"`
+	NextDelay     float64 `json:"next_delay"`
+}
+
+func writeNDJSONAttempt(w io.Writer, attempt recur.Attempt, wait time.Duration) {
+	data := ndjsonAttempt{
+		Type:          "attempt",
+		Attempt:       attempt.Number,
+		StartedAt:     attempt.StartedAt.Format(time.RFC3339Nano),
+		EndedAt:       attempt.EndedAt.Format(time.RFC3339Nano),
+		Duration:      attempt.Duration.Seconds(),
+		ExitCode:      attempt.ExitCode,
+		StderrSnippet: attempt.StderrSnippet,
+		NextDelay:     wait.Seconds(),
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to marshal NDJSON attempt line: %v", err)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", jsonData)
+}
+
+// ndjsonSummary is the final "-R ndjson:" line, written once the retry
+// loop finishes, so a reader streaming the file knows where it ends and
+// sees the same totals the other report formats give at the end of a run.
+type ndjsonSummary struct {
+	Type             string  `json:"type"`
+	Attempts         int     `json:"attempts"`
+	Successes        int     `json:"successes"`
+	Failures         int     `json:"failures"`
+	BreakerTripped   bool    `json:"breaker_tripped"`
+	BreakerTrippedAt int     `json:"breaker_tripped_at"`
+	TotalTime        float64 `json:"total_time"`
+}
+
+func writeNDJSONSummary(w io.Writer, stats recur.Stats) {
+	data := ndjsonSummary{
+		Type:             "summary",
+		Attempts:         stats.Attempts,
+		Successes:        stats.Successes,
+		Failures:         stats.Failures,
+		BreakerTripped:   stats.BreakerTripped,
+		BreakerTrippedAt: stats.BreakerTrippedAt,
+		TotalTime:        stats.TotalTime.Seconds(),
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to marshal NDJSON summary line: %v", err)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", jsonData)
+}
+
+// attemptEvent is one JSON Lines entry --events writes per attempt, as it
+// happens, on a side channel independent of -R/--report.
+type attemptEvent struct {
+	Attempt      int    `json:"attempt"`
+	StartedAt    string `json:"started_at"`
+	EndedAt      string `json:"ended_at"`
+	ExitCode     int    `json:"exit_code"`
+	TimedOut     bool   `json:"timed_out"`
+	ConditionMet bool   `json:"condition_met"`
+	WaitMs       int64  `json:"wait_ms"`
+	StdoutBytes  int    `json:"stdout_bytes"`
+	StderrBytes  int    `json:"stderr_bytes"`
+}
+
+func writeAttemptEvent(w io.Writer, attempt recur.Attempt, success bool, wait time.Duration) {
+	data := attemptEvent{
+		Attempt:      attempt.Number,
+		StartedAt:    attempt.StartedAt.Format(time.RFC3339Nano),
+		EndedAt:      attempt.EndedAt.Format(time.RFC3339Nano),
+		ExitCode:     attempt.ExitCode,
+		TimedOut:     attempt.TimedOut,
+		ConditionMet: success,
+		WaitMs:       wait.Milliseconds(),
+		StdoutBytes:  attempt.StdoutBytes,
+		StderrBytes:  attempt.StderrBytes,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("failed to marshal attempt event to JSON: %v", err)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", jsonData)
 }
 
 func main() {
-	config := parseArgs()
+	if len(os.Args) > 1 && os.Args[1] == "repl" {
+		os.Exit(runRepl(os.Args[2:]))
+	}
 
-	// Initialize the random number generator for jitter.
-	var pcg *rand.PCG
-	//nolint:gosec
-	if config.RandomSeed == randomSeedDefault {
-		pcg = rand.NewPCG(rand.Uint64(), rand.Uint64())
-	} else {
-		pcg = rand.NewPCG(config.RandomSeed, 0)
+	if len(os.Args) > 1 && os.Args[1] == "verify-release" {
+		os.Exit(runVerifyRelease(os.Args[2:]))
 	}
 
+	config := parseArgs()
+
 	// Configure logging.
 	customWriter := &elapsedTimeWriter{
 		startTime: time.Now(),
@@ -941,13 +1468,110 @@ func main() {
 		log.Printf("configuration:\n%s\n", repr.String(config, repr.Indent("\t"), repr.OmitEmpty(false)))
 	}
 
-	//nolint:gosec
-	exitCode, stats, err := retry(config, stdinContent, rand.New(pcg))
+	var program *recur.ConditionProgram
+	if config.ConditionFile != "" {
+		var err error
+
+		program, err = recur.LoadConditionFile(config.ConditionFile, config.StarlarkModules)
+		if err != nil {
+			log.Printf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	delayStrategies := config.Strategies
+	if len(delayStrategies) == 0 {
+		delayStrategies = legacyDelayStrategies(config)
+	}
+
+	cfg := recur.Config{
+		Command:            config.Command,
+		Args:               config.Args,
+		BreakerConsecutive: config.BreakerConsecutive,
+		BreakerMaxFailures: config.BreakerMaxFailures,
+		BreakerWindow:      config.BreakerWindow,
+		Condition:          config.Condition,
+		ConditionProgram:   program,
+		DelayStrategies:    delayStrategies,
+		HoldStderr:         config.HoldStderr,
+		HoldStdout:         config.HoldStdout,
+		MaxAttempts:        config.MaxAttempts,
+		RandomSeed:         config.RandomSeed,
+		ReplayStdin:        config.ReplayStdin,
+		Reset:              config.Reset,
+		StarlarkModules:    config.StarlarkModules,
+		Timeout:            config.Timeout,
+		Verbose:            config.Verbose,
+	}
+
+	if config.ReplayStdin {
+		cfg.Stdin = bytes.NewReader(stdinContent)
+	}
+
+	// "ndjson" streams one line per attempt by definition, regardless of
+	// --report-mode; the other formats only stream when --report-mode
+	// stream asks for it.
+	streamReport := config.Report == reportFormatNDJSON ||
+		(config.ReportMode == reportModeStream && config.Report != reportFormatNone)
+
+	var reportStream io.WriteCloser
+	if streamReport {
+		reportStream = newReportStreamWriter(config.ReportFile, config.ReportRotateSize, config.ReportRotateAge)
+	}
+
+	var eventsSink io.WriteCloser
+	if config.EventsFormat != eventsFormatNone {
+		sink, err := openEventsSink(config.EventsTarget)
+		if err != nil {
+			log.Printf("failed to open events sink: %v", err)
+			os.Exit(1)
+		}
+
+		eventsSink = sink
+	}
+
+	if reportStream != nil || eventsSink != nil {
+		cfg.OnAttempt = func(attempt recur.Attempt, success bool, wait time.Duration) {
+			if reportStream != nil {
+				if config.Report == reportFormatNDJSON {
+					writeNDJSONAttempt(reportStream, attempt, wait)
+				} else {
+					writeAttemptReport(reportStream, attempt, success, wait)
+				}
+			}
+
+			if eventsSink != nil {
+				writeAttemptEvent(eventsSink, attempt, success, wait)
+			}
+		}
+	}
+
+	stats, err := recur.Run(context.Background(), cfg)
 	if err != nil {
 		log.Printf("%v", err)
 	}
 
-	generateReport(stats, config.Report, config.ReportFile)
+	if reportStream != nil {
+		if config.Report == reportFormatNDJSON {
+			writeNDJSONSummary(reportStream, stats)
+		}
+
+		if closeErr := reportStream.Close(); closeErr != nil {
+			log.Printf("failed to close report file: %v", closeErr)
+		}
+	} else {
+		generateReport(stats, config.Report, config.ReportFile)
+	}
+
+	if eventsSink != nil {
+		if closeErr := eventsSink.Close(); closeErr != nil {
+			log.Printf("failed to close events sink: %v", closeErr)
+		}
+	}
+
+	if saveErr := saveLastAttempt(config, stats); saveErr != nil && config.Verbose >= verboseLevelConditionDetails {
+		log.Printf("failed to save repl fixture: %v", saveErr)
+	}
 
-	os.Exit(exitCode)
+	os.Exit(stats.ExitCode)
 }
@@ -29,6 +29,10 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -405,6 +409,182 @@ func TestConditionReSearchStdout(t *testing.T) {
 	})
 }
 
+func TestConditionJSONStdout(t *testing.T) {
+	const body = `{"status": {"code": 429}, "retry_after": 30}`
+
+	t.Run("match", func(t *testing.T) {
+		_, _, err := runCommandWithStdin(body, "-O", "-c", `stdout.json("$.retry_after") == 30`, commandCat)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("nested path", func(t *testing.T) {
+		_, _, err := runCommandWithStdin(body, "-O", "-c", `stdout.json("$.status.code") == 429`, commandCat)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("missing path and default", func(t *testing.T) {
+		_, _, err := runCommandWithStdin(body, "-O", "-c", `stdout.json("$.nope") == None and stdout.json("$.nope", default=1) == 1`, commandCat)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		_, stderr, err := runCommandWithStdin("not json", "-O", "-c", `stdout.json("$.retry_after")`, commandCat)
+
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+
+		if !strings.Contains(stderr, "invalid JSON") {
+			t.Errorf("Expected 'invalid JSON' in stderr, got %q", stderr)
+		}
+	})
+
+	t.Run("without -O flag", func(t *testing.T) {
+		_, stderr, err := runCommandWithStdin(body, "-c", `stdout.json("$.retry_after")`, commandCat)
+
+		if err == nil {
+			t.Error("Expected an error, got nil")
+		}
+
+		if matched, _ := regexp.MatchString("has no .json field or method", stderr); !matched {
+			t.Error("Expected 'has no .json field or method' in stderr")
+		}
+	})
+}
+
+func TestConditionJSONPathFunction(t *testing.T) {
+	_, _, err := runCommand("-c", `jsonpath('{"code": 429}', "$.code") == 429`, commandHello)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestVerifyReleaseChecksumMismatch(t *testing.T) {
+	tempDir := t.TempDir()
+	tarball := filepath.Join(tempDir, "recur-v3.1.0-linux-x86_64.tar.gz")
+	sums := filepath.Join(tempDir, "SHA512SUMS.txt")
+
+	if err := os.WriteFile(tarball, []byte("not the real release"), 0o644); err != nil {
+		t.Fatalf("Failed to write tarball: %v", err)
+	}
+
+	if err := os.WriteFile(sums, []byte("0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000  recur-v3.1.0-linux-x86_64.tar.gz\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write checksum file: %v", err)
+	}
+
+	_, stderr, err := runCommand("verify-release", "--sums", sums, tarball)
+	if err == nil {
+		t.Error("Expected an error for a checksum mismatch, got nil")
+	}
+
+	if !strings.Contains(stderr, "checksum mismatch") {
+		t.Errorf("Expected 'checksum mismatch' in stderr, got %q", stderr)
+	}
+}
+
+func TestReplSyntheticFixture(t *testing.T) {
+	// An empty state directory has no cached last attempt to load.
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	stdout, _, _ := runCommandWithStdin("attempt\n", "repl")
+
+	if !strings.Contains(stdout, "1") {
+		t.Errorf("Expected synthetic fixture's attempt == 1 in repl output, got %q", stdout)
+	}
+}
+
+func TestReplFixtureFile(t *testing.T) {
+	tempDir := t.TempDir()
+	fixtureFile := filepath.Join(tempDir, "fixture.json")
+
+	fixture := `{"attempt": 7, "code": 1, "stdout": "rate limited"}`
+	if err := os.WriteFile(fixtureFile, []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	stdout, _, _ := runCommandWithStdin(`stdout.search("limited")`+"\n", "repl", "--fixture", fixtureFile)
+
+	if !strings.Contains(stdout, "limited") {
+		t.Errorf("Expected fixture stdout content reflected in repl output, got %q", stdout)
+	}
+}
+
+func TestConditionFile(t *testing.T) {
+	tempDir := t.TempDir()
+	policyFile := filepath.Join(tempDir, "policy.star")
+
+	policy := "def should_retry(ctx):\n    return ctx.code == 0\n"
+	if err := os.WriteFile(policyFile, []byte(policy), 0o644); err != nil {
+		t.Fatalf("Failed to write condition file: %v", err)
+	}
+
+	_, _, err := runCommand("--condition-file", policyFile, commandHello)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestConditionFileMissingEntryPoint(t *testing.T) {
+	tempDir := t.TempDir()
+	policyFile := filepath.Join(tempDir, "policy.star")
+
+	if err := os.WriteFile(policyFile, []byte("x = 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write condition file: %v", err)
+	}
+
+	_, stderr, err := runCommand("--condition-file", policyFile, commandHello)
+	if err == nil {
+		t.Error("Expected an error, got nil")
+	}
+
+	if !strings.Contains(stderr, "should_retry") {
+		t.Error("Expected error to mention should_retry")
+	}
+}
+
+func TestConditionStdlibModules(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		_, _, err := runCommand("-O", "-c", `json.decode(stdout.read())["ok"] == True`, commandHello)
+
+		if err == nil {
+			t.Error("Expected an error because hello prints plain text, not JSON")
+		}
+	})
+
+	t.Run("re", func(t *testing.T) {
+		_, _, err := runCommand("-c", `re.match("h.*o", "hello") == "hello"`, commandHello)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		_, _, err := runCommand("-c", `hash.md5("") == "d41d8cd98f00b204e9800998ecf8427e"`, commandHello)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("math", func(t *testing.T) {
+		_, _, err := runCommand("-c", `math.floor(1.9) == 1`, commandHello)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
+
 func TestConditionReSearchStderr(t *testing.T) {
 	t.Run("simple match", func(t *testing.T) {
 		_, _, err := runCommand("-E", "-c", `stderr.search("hello")`, commandHello, "--stderr")
@@ -695,6 +875,65 @@ func TestReportStderrText(t *testing.T) {
 	}
 }
 
+func TestPrintReportSchema(t *testing.T) {
+	stdout, _, err := runCommand("--print-report-schema")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &schema); err != nil {
+		t.Fatalf("Expected valid JSON, got parse error: %v", err)
+	}
+
+	if dialect, ok := schema["$schema"].(string); !ok || !strings.Contains(dialect, "2020-12") {
+		t.Errorf("Expected a draft 2020-12 $schema, got %v", schema["$schema"])
+	}
+}
+
+func compileReportSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+
+	stdout, _, err := runCommand("--print-report-schema")
+	if err != nil {
+		t.Fatalf("Failed to print report schema: %v", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("report_schema.json", strings.NewReader(stdout)); err != nil {
+		t.Fatalf("Failed to add report schema resource: %v", err)
+	}
+
+	schema, err := compiler.Compile("report_schema.json")
+	if err != nil {
+		t.Fatalf("Failed to compile report schema: %v", err)
+	}
+
+	return schema
+}
+
+func TestReportJSONMatchesSchema(t *testing.T) {
+	schema := compileReportSchema(t)
+	tempDir := t.TempDir()
+	jsonFile := filepath.Join(tempDir, "report.json")
+
+	_, _, _ = runCommand("-R", jsonFile, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report interface{}
+	if err := json.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v", err)
+	}
+
+	if err := schema.Validate(report); err != nil {
+		t.Errorf("Report failed schema validation: %v", err)
+	}
+}
+
 func TestReportFileJSON(t *testing.T) {
 	tempDir := t.TempDir()
 	jsonFile := filepath.Join(tempDir, "report.json")
@@ -808,3 +1047,437 @@ func TestReportFormatOverride(t *testing.T) {
 		}
 	})
 }
+
+func TestReportModeStream(t *testing.T) {
+	tempDir := t.TempDir()
+	streamFile := filepath.Join(tempDir, "report.jsonl")
+
+	_, _, _ = runCommand("-R", "json", "--report-file", streamFile, "--report-mode", "stream", "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(streamFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(reportData), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 streamed attempt lines, got %d: %v", len(lines), lines)
+	}
+
+	for i, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse streamed line %d: %v", i, err)
+		}
+
+		if attempt, ok := entry["attempt"].(float64); !ok || int(attempt) != i+1 {
+			t.Errorf("Expected attempt %d, got %v", i+1, entry["attempt"])
+		}
+
+		if exitCode, ok := entry["exit_code"].(float64); !ok || exitCode != 99 {
+			t.Errorf("Expected exit code 99, got %v", entry["exit_code"])
+		}
+	}
+}
+
+func TestReportNDJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	ndjsonFile := filepath.Join(tempDir, "report.ndjson")
+
+	_, _, _ = runCommand("-R", "ndjson:"+ndjsonFile, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(ndjsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(reportData), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 3 attempt lines and 1 summary line, got %d: %v", len(lines), lines)
+	}
+
+	for i, line := range lines[:3] {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse attempt line %d: %v", i, err)
+		}
+
+		if entryType, ok := entry["type"].(string); !ok || entryType != "attempt" {
+			t.Errorf("Expected line %d type to be \"attempt\", got %v", i, entry["type"])
+		}
+
+		if attempt, ok := entry["attempt"].(float64); !ok || int(attempt) != i+1 {
+			t.Errorf("Expected attempt %d, got %v", i+1, entry["attempt"])
+		}
+
+		if exitCode, ok := entry["exit_code"].(float64); !ok || exitCode != 99 {
+			t.Errorf("Expected exit code 99, got %v", entry["exit_code"])
+		}
+
+		for _, field := range []string{"started_at", "ended_at", "duration", "stderr_snippet", "next_delay"} {
+			if _, ok := entry[field]; !ok {
+				t.Errorf("Expected attempt line %d to have field %q", i, field)
+			}
+		}
+	}
+
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[3]), &summary); err != nil {
+		t.Fatalf("Failed to parse summary line: %v", err)
+	}
+
+	if summaryType, ok := summary["type"].(string); !ok || summaryType != "summary" {
+		t.Errorf("Expected summary line type to be \"summary\", got %v", summary["type"])
+	}
+
+	if attempts, ok := summary["attempts"].(float64); !ok || attempts != 3 {
+		t.Errorf("Expected summary attempts to be 3, got %v", summary["attempts"])
+	}
+}
+
+func TestReportNDJSONStreamsIncrementally(t *testing.T) {
+	tempDir := t.TempDir()
+	ndjsonFile := filepath.Join(tempDir, "report.ndjson")
+
+	cmd := exec.Command(commandRecur, "-R", "ndjson:"+ndjsonFile, "-a", "3", "-d", "300ms", "-c", "False", commandExit99)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start command: %v", err)
+	}
+
+	sawPartial := false
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(ndjsonFile)
+		if err == nil {
+			lines := strings.Count(strings.TrimRight(string(data), "\n"), "\n") + 1
+			if len(data) > 0 && lines > 0 && lines < 4 {
+				sawPartial = true
+
+				break
+			}
+		}
+
+		time.Sleep(25 * time.Millisecond)
+	}
+
+	_ = cmd.Wait()
+
+	if !sawPartial {
+		t.Error("Expected to observe a partial NDJSON report file while the retry loop was still running")
+	}
+
+	data, err := os.ReadFile(ndjsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read final report file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 3 attempt lines and 1 summary line once the run finished, got %d: %v", len(lines), lines)
+	}
+}
+
+func TestReportModeStreamRotateSize(t *testing.T) {
+	tempDir := t.TempDir()
+	streamFile := filepath.Join(tempDir, "report.jsonl")
+
+	_, _, _ = runCommand("-R", "json", "--report-file", streamFile, "--report-mode", "stream", "--report-rotate-size", "1B", "-a", "3", "-c", "False", commandExit99)
+
+	entries, err := filepath.Glob(streamFile + "*")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+
+	if len(entries) < 2 {
+		t.Errorf("Expected the report file to rotate at least once, got entries %v", entries)
+	}
+}
+
+func TestBreakerConsecutive(t *testing.T) {
+	_, stderr, err := runCommand("--breaker-consecutive", "2", "-a", "10", "-c", "False", commandExit99)
+
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 125 {
+		t.Errorf("Expected exit status 125, got %v", err)
+	}
+
+	if !strings.Contains(stderr, "circuit breaker tripped") {
+		t.Errorf("Expected a circuit breaker log line, got %q", stderr)
+	}
+}
+
+func TestBreakerWindowReport(t *testing.T) {
+	tempDir := t.TempDir()
+	jsonFile := filepath.Join(tempDir, "report.json")
+
+	_, _, _ = runCommand("-R", jsonFile, "--breaker", "2/1h", "-a", "10", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := json.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v", err)
+	}
+
+	if tripped, ok := report["breaker_tripped"].(bool); !ok || !tripped {
+		t.Errorf("Expected breaker_tripped to be true, got %v", report["breaker_tripped"])
+	}
+
+	if trippedAt, ok := report["breaker_tripped_at"].(float64); !ok || trippedAt != 3 {
+		t.Errorf("Expected breaker_tripped_at to be 3, got %v", report["breaker_tripped_at"])
+	}
+}
+
+func TestReportStderrYAML(t *testing.T) {
+	_, stderr, _ := runCommand("-R", "yaml:-", "-a", "3", "-c", "False", commandExit99)
+
+	if !strings.Contains(stderr, "attempts: 3") {
+		t.Error("Expected YAML report in stderr")
+	}
+}
+
+func TestReportFileYAML(t *testing.T) {
+	tempDir := t.TempDir()
+	yamlFile := filepath.Join(tempDir, "report.yaml")
+
+	_, _, _ = runCommand("-R", yamlFile, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(yamlFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := yaml.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Failed to parse YAML report: %v", err)
+	}
+
+	if attempts, ok := report["attempts"].(int); !ok || attempts != 3 {
+		t.Errorf("Expected attempts to be 3, got %v", report["attempts"])
+	}
+
+	exitCodes, ok := report["exit_codes"].([]interface{})
+	if !ok {
+		t.Errorf("Expected exit_codes to be a sequence, got %T", report["exit_codes"])
+	}
+
+	if len(exitCodes) != 3 {
+		t.Errorf("Expected 3 exit codes, got %d", len(exitCodes))
+	}
+}
+
+func TestReportYAMLPrefixOverride(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "report.json")
+
+	_, _, _ = runCommand("-R", "yaml:"+file, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := yaml.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Expected YAML format, got parse error: %v", err)
+	}
+
+	if strings.Contains(string(reportData), `"attempts"`) {
+		t.Error("Expected YAML format, got JSON")
+	}
+}
+
+func TestReportFileYAMLYmlExtension(t *testing.T) {
+	tempDir := t.TempDir()
+	ymlFile := filepath.Join(tempDir, "report.yml")
+
+	_, _, _ = runCommand("-R", ymlFile, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(ymlFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := yaml.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Expected YAML format for a .yml extension, got parse error: %v", err)
+	}
+
+	if attempts, ok := report["attempts"].(int); !ok || attempts != 3 {
+		t.Errorf("Expected attempts to be 3, got %v", report["attempts"])
+	}
+}
+
+func TestReportFileAtomicNoTempLeftover(t *testing.T) {
+	tempDir := t.TempDir()
+	jsonFile := filepath.Join(tempDir, "report.json")
+
+	_, _, _ = runCommand("-R", jsonFile, "-a", "3", "-c", "False", commandExit99)
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "report.json" {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+
+		t.Errorf("Expected only report.json in %s, got %v", tempDir, names)
+	}
+}
+
+func TestReportFileAtomicReplacesExisting(t *testing.T) {
+	tempDir := t.TempDir()
+	jsonFile := filepath.Join(tempDir, "report.json")
+
+	if err := os.WriteFile(jsonFile, []byte("stale report, should be fully replaced"), 0o644); err != nil {
+		t.Fatalf("Failed to seed existing report file: %v", err)
+	}
+
+	_, _, _ = runCommand("-R", jsonFile, "-a", "3", "-c", "False", commandExit99)
+
+	reportData, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to read report file: %v", err)
+	}
+
+	var report map[string]interface{}
+	if err := json.Unmarshal(reportData, &report); err != nil {
+		t.Fatalf("Expected the stale report to be replaced by a complete one, got parse error: %v", err)
+	}
+
+	if attempts, ok := report["attempts"].(float64); !ok || attempts != 3 {
+		t.Errorf("Expected attempts to be 3, got %v", report["attempts"])
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "recur.yaml")
+
+	config := "attempts: 5\ncondition: \"False\"\n"
+	if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	t.Run("file value applies", func(t *testing.T) {
+		_, stderr, _ := runCommand("--config", configFile, "-R", "text:-", commandExit99)
+
+		if !strings.Contains(stderr, "Total attempts: 5\n") {
+			t.Errorf("Expected config file attempts to apply, got %q", stderr)
+		}
+	})
+
+	t.Run("CLI flag overrides file value", func(t *testing.T) {
+		_, stderr, _ := runCommand("--config", configFile, "-a", "2", "-R", "text:-", commandExit99)
+
+		if !strings.Contains(stderr, "Total attempts: 2\n") {
+			t.Errorf("Expected CLI attempts to override config file, got %q", stderr)
+		}
+	})
+}
+
+func parseEventLines(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	events := make([]map[string]interface{}, len(lines))
+
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &events[i]); err != nil {
+			t.Fatalf("Failed to parse event line %d: %v", i, err)
+		}
+	}
+
+	return events
+}
+
+func TestEventsFailureRun(t *testing.T) {
+	tempDir := t.TempDir()
+	eventsFile := filepath.Join(tempDir, "events.jsonl")
+
+	_, _, _ = runCommand("--events", "jsonl:"+eventsFile, "-a", "3", "-c", "False", commandExit99)
+
+	data, err := os.ReadFile(eventsFile)
+	if err != nil {
+		t.Fatalf("Failed to read events file: %v", err)
+	}
+
+	events := parseEventLines(t, data)
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %v", len(events), events)
+	}
+
+	for i, event := range events {
+		if attempt, ok := event["attempt"].(float64); !ok || int(attempt) != i+1 {
+			t.Errorf("Expected event %d attempt to be %d, got %v", i, i+1, event["attempt"])
+		}
+
+		if exitCode, ok := event["exit_code"].(float64); !ok || exitCode != 99 {
+			t.Errorf("Expected event %d exit_code to be 99, got %v", i, event["exit_code"])
+		}
+
+		if conditionMet, ok := event["condition_met"].(bool); !ok || conditionMet {
+			t.Errorf("Expected event %d condition_met to be false, got %v", i, event["condition_met"])
+		}
+
+		if timedOut, ok := event["timed_out"].(bool); !ok || timedOut {
+			t.Errorf("Expected event %d timed_out to be false, got %v", i, event["timed_out"])
+		}
+
+		for _, field := range []string{"started_at", "ended_at", "wait_ms", "stdout_bytes", "stderr_bytes"} {
+			if _, ok := event[field]; !ok {
+				t.Errorf("Expected event %d to have field %q", i, field)
+			}
+		}
+	}
+}
+
+func TestEventsSuccessRun(t *testing.T) {
+	tempDir := t.TempDir()
+	eventsFile := filepath.Join(tempDir, "events.jsonl")
+
+	_, _, _ = runCommand("--events", "ndjson:"+eventsFile, commandHello)
+
+	data, err := os.ReadFile(eventsFile)
+	if err != nil {
+		t.Fatalf("Failed to read events file: %v", err)
+	}
+
+	events := parseEventLines(t, data)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d: %v", len(events), events)
+	}
+
+	if conditionMet, ok := events[0]["condition_met"].(bool); !ok || !conditionMet {
+		t.Errorf("Expected condition_met to be true, got %v", events[0]["condition_met"])
+	}
+
+	if exitCode, ok := events[0]["exit_code"].(float64); !ok || exitCode != 0 {
+		t.Errorf("Expected exit_code to be 0, got %v", events[0]["exit_code"])
+	}
+}
+
+func TestEventsStdout(t *testing.T) {
+	stdout, stderr, _ := runCommand("--events", "jsonl:-", "-a", "2", "-c", "False", commandExit99)
+
+	events := parseEventLines(t, []byte(stdout))
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events in stdout, got %d: %v", len(events), events)
+	}
+
+	if strings.TrimSpace(stderr) == "" {
+		t.Fatal("Expected recur's own summary diagnostic on stderr")
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(stderr), &event); err == nil {
+		t.Error("Expected stderr to carry recur's diagnostic, not a JSON event line")
+	}
+}
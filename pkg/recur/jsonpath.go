@@ -0,0 +1,257 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recur
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegmentKind distinguishes the handful of JSONPath operators
+// jsonPathEval understands: plain child access, array/wildcard access,
+// and recursive descent.
+type jsonPathSegmentKind int
+
+const (
+	jsonPathKey jsonPathSegmentKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+	jsonPathRecursiveKey
+)
+
+type jsonPathSegment struct {
+	kind  jsonPathSegmentKind
+	key   string
+	index int
+}
+
+// parseJSONPath tokenizes a small subset of JSONPath: "$", ".key",
+// ["key"], [n], [*], and recursive descent "..key". It's enough for
+// typical CLI JSON scraping, not a full JSONPath implementation.
+func parseJSONPath(expr string) ([]jsonPathSegment, error) {
+	rest := strings.TrimSpace(expr)
+	rest = strings.TrimPrefix(rest, "$")
+
+	var segments []jsonPathSegment
+
+	for len(rest) > 0 {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			rest = rest[2:]
+
+			key, remainder := splitJSONPathKey(rest)
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath: expected a key after '..'")
+			}
+
+			segments = append(segments, jsonPathSegment{kind: jsonPathRecursiveKey, key: key})
+			rest = remainder
+
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+
+			key, remainder := splitJSONPathKey(rest)
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath: expected a key after '.'")
+			}
+
+			segments = append(segments, jsonPathSegment{kind: jsonPathKey, key: key})
+			rest = remainder
+
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unterminated '['")
+			}
+
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				segments = append(segments, jsonPathSegment{kind: jsonPathWildcard})
+
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, jsonPathSegment{kind: jsonPathKey, key: inner[1 : len(inner)-1]})
+
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath: invalid index %q", inner)
+				}
+
+				segments = append(segments, jsonPathSegment{kind: jsonPathIndex, index: index})
+			}
+
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected character %q", rest[:1])
+		}
+	}
+
+	return segments, nil
+}
+
+// splitJSONPathKey reads a bare identifier up to the next '.' or '[',
+// returning it and whatever's left of the expression.
+func splitJSONPathKey(s string) (key string, rest string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], s[i:]
+}
+
+// jsonPathEval parses data as JSON and evaluates a JSONPath expression
+// against it. found is false when the path doesn't match anything, so
+// callers can fall back to a default without treating a missing path as
+// an error. Malformed JSON or a malformed expression is always an error.
+func jsonPathEval(data []byte, expr string) (value any, found bool, err error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, false, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	segments, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := []any{root}
+
+	for _, seg := range segments {
+		var next []any
+
+		for _, v := range current {
+			next = append(next, applyJSONPathSegment(seg, v)...)
+		}
+
+		current = next
+	}
+
+	switch len(current) {
+	case 0:
+		return nil, false, nil
+
+	case 1:
+		return current[0], true, nil
+
+	default:
+		return current, true, nil
+	}
+}
+
+func applyJSONPathSegment(seg jsonPathSegment, v any) []any {
+	switch seg.kind {
+	case jsonPathKey:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		child, ok := m[seg.key]
+		if !ok {
+			return nil
+		}
+
+		return []any{child}
+
+	case jsonPathIndex:
+		a, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+
+		index := seg.index
+		if index < 0 {
+			index += len(a)
+		}
+
+		if index < 0 || index >= len(a) {
+			return nil
+		}
+
+		return []any{a[index]}
+
+	case jsonPathWildcard:
+		switch typed := v.(type) {
+		case map[string]any:
+			return sortedMapValues(typed)
+
+		case []any:
+			return append([]any{}, typed...)
+
+		default:
+			return nil
+		}
+
+	case jsonPathRecursiveKey:
+		var matches []any
+		collectRecursive(v, seg.key, &matches)
+
+		return matches
+
+	default:
+		return nil
+	}
+}
+
+// sortedMapValues returns a map's values in key order, so wildcard
+// expansion is deterministic instead of following Go's random map order.
+func sortedMapValues(m map[string]any) []any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+
+	return values
+}
+
+// collectRecursive implements ".." by walking the whole tree in
+// deterministic (sorted-key) order and collecting every value found
+// under the given key, at any depth.
+func collectRecursive(v any, key string, out *[]any) {
+	switch typed := v.(type) {
+	case map[string]any:
+		if child, ok := typed[key]; ok {
+			*out = append(*out, child)
+		}
+
+		for _, child := range sortedMapValues(typed) {
+			collectRecursive(child, key, out)
+		}
+
+	case []any:
+		for _, child := range typed {
+			collectRecursive(child, key, out)
+		}
+	}
+}
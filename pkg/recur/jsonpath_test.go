@@ -0,0 +1,110 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recur
+
+import "testing"
+
+const jsonPathFixture = `{
+	"status": {"code": 429},
+	"retry_after": 30,
+	"items": [{"name": "a"}, {"name": "b"}],
+	"nested": {"items": [{"name": "c"}]}
+}`
+
+func TestJSONPathEvalMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"$.retry_after", float64(30)},
+		{"$.status.code", float64(429)},
+		{`$["status"]["code"]`, float64(429)},
+		{"$.items[0].name", "a"},
+		{"$.items[1].name", "b"},
+	}
+
+	for _, tt := range tests {
+		got, found, err := jsonPathEval([]byte(jsonPathFixture), tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+
+			continue
+		}
+
+		if !found {
+			t.Errorf("%s: expected a match, got none", tt.expr)
+
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestJSONPathEvalWildcardAndRecursive(t *testing.T) {
+	names, found, err := jsonPathEval([]byte(jsonPathFixture), "$.items[*].name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !found {
+		t.Fatal("expected a match")
+	}
+
+	list, ok := names.([]any)
+	if !ok || len(list) != 2 || list[0] != "a" || list[1] != "b" {
+		t.Errorf("expected [a b], got %v", names)
+	}
+
+	recursive, found, err := jsonPathEval([]byte(jsonPathFixture), "$..name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !found {
+		t.Fatal("expected a match")
+	}
+
+	list, ok = recursive.([]any)
+	if !ok || len(list) != 3 {
+		t.Errorf("expected 3 recursive matches, got %v", recursive)
+	}
+}
+
+func TestJSONPathEvalMissingPath(t *testing.T) {
+	_, found, err := jsonPathEval([]byte(jsonPathFixture), "$.does.not.exist")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if found {
+		t.Error("expected no match for a missing path")
+	}
+}
+
+func TestJSONPathEvalMalformedJSON(t *testing.T) {
+	_, _, err := jsonPathEval([]byte("not json"), "$.status")
+	if err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
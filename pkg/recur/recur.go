@@ -0,0 +1,572 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package recur is the reusable core of recur: retrying a command with
+// backoff and jitter until a Starlark condition is met. The recur binary
+// is a thin CLI wrapper around this package; embedders can call Run
+// directly to drive the same retry loop from their own Go program.
+package recur
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+)
+
+const (
+	EnvVarAttempt           = "RECUR_ATTEMPT"
+	EnvVarAttemptSinceReset = "RECUR_ATTEMPT_SINCE_RESET"
+	EnvVarMaxAttempts       = "RECUR_MAX_ATTEMPTS"
+
+	ExitCodeBreakerTripped  = 125
+	ExitCodeCommandNotFound = 127
+	ExitCodeError           = 255
+	ExitCodeTimeout         = 124
+
+	invSqrt5 = 0.4472135954999579
+)
+
+// Interval is an inclusive [Start, End] range of durations, used for the
+// fixed-delay and jitter ranges.
+type Interval struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Attempt describes a single attempt at running the command, as seen by
+// the retry condition.
+type Attempt struct {
+	CommandFound     bool
+	Duration         time.Duration
+	EndedAt          time.Time
+	ExitCode         int
+	MaxAttempts      int
+	Number           int
+	NumberSinceReset int
+	StartedAt        time.Time
+	StderrBytes      int
+	StderrSnippet    string
+	StdoutBytes      int
+	TimedOut         bool
+	TotalTime        time.Duration
+}
+
+// maxStderrSnippetBytes caps StderrSnippet so report sinks that embed it
+// per attempt (such as recur's "-R ndjson:" format) stay line-sized even
+// when the command is chatty on stderr.
+const maxStderrSnippetBytes = 256
+
+// stderrSnippet truncates stderr to maxStderrSnippetBytes for embedding in
+// a per-attempt report line.
+func stderrSnippet(stderr []byte) string {
+	if len(stderr) <= maxStderrSnippetBytes {
+		return string(stderr)
+	}
+
+	return string(stderr[:maxStderrSnippetBytes])
+}
+
+type commandStatus int
+
+const (
+	statusFinished commandStatus = iota
+	statusTimeout
+	statusNotFound
+	statusUnknownError
+)
+
+type commandResult struct {
+	Status   commandStatus
+	ExitCode int
+}
+
+// Stats is the outcome of a Run call: one slice entry per attempt, plus
+// totals. The recur CLI renders this as its -R/--report output.
+type Stats struct {
+	Attempts         int
+	BreakerTripped   bool
+	BreakerTrippedAt int
+	CommandFound     []bool
+	ConditionResults []bool
+	ExitCode         int
+	ExitCodes        []int
+	Failures         int
+	Successes        int
+	TotalTime        time.Duration
+	WaitTimes        []time.Duration
+
+	// LastStdout and LastStderr hold the most recent attempt's captured
+	// output. They aren't part of the JSON/text report.
+	LastStdout []byte `json:"-"`
+	LastStderr []byte `json:"-"`
+}
+
+// Config configures a Run call. The zero value isn't directly usable:
+// at minimum Command, MaxAttempts, and either Condition or
+// ConditionProgram must be set. A nil DelayStrategies means no delay
+// between attempts.
+type Config struct {
+	Command          string
+	Args             []string
+	Condition        string
+	ConditionProgram *ConditionProgram
+	DelayStrategies  []DelayStrategy
+	HoldStderr       bool
+	HoldStdout       bool
+	MaxAttempts      int
+	RandomSeed       uint64
+	ReplayStdin      bool
+	Reset            time.Duration
+	StarlarkModules  []string
+
+	// Timeout bounds each attempt. The zero value and any negative
+	// duration both mean no timeout; only a positive value applies one.
+	Timeout time.Duration
+	Verbose int
+
+	// BreakerMaxFailures and BreakerWindow implement a rolling-window
+	// circuit breaker: Run aborts with ExitCodeBreakerTripped once more
+	// than BreakerMaxFailures attempts have failed within the trailing
+	// BreakerWindow. Either being zero disables this trigger.
+	BreakerMaxFailures int
+	BreakerWindow      time.Duration
+
+	// BreakerConsecutive aborts Run after this many back-to-back
+	// failures, regardless of BreakerWindow. Zero disables it.
+	BreakerConsecutive int
+
+	// Stdout, Stderr, and Stdin default to os.Stdout, os.Stderr, and
+	// os.Stdin. Overriding them lets a caller embed recur without the
+	// child process inheriting the host process's standard streams.
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// RandSource seeds the jitter random number generator. It defaults
+	// to an automatically seeded source, or a source derived from
+	// RandomSeed when RandomSeed is nonzero.
+	RandSource rand.Source
+
+	// Sleep overrides the delay between attempts. It defaults to a
+	// timer-based sleep that returns early once ctx is done, and exists so
+	// tests and supervisors can use a fake clock instead of waiting in
+	// real time.
+	Sleep func(ctx context.Context, d time.Duration)
+
+	// OnAttempt, when set, is called once per attempt right after its
+	// condition is evaluated, with the wait that preceded the attempt
+	// and whether the condition succeeded. It lets a CLI wrapper stream
+	// a per-attempt report as the retry loop runs instead of only
+	// generating one at the very end.
+	OnAttempt func(attempt Attempt, success bool, wait time.Duration)
+}
+
+func (c Config) stdout() io.Writer {
+	if c.Stdout != nil {
+		return c.Stdout
+	}
+
+	return os.Stdout
+}
+
+func (c Config) stderr() io.Writer {
+	if c.Stderr != nil {
+		return c.Stderr
+	}
+
+	return os.Stderr
+}
+
+func (c Config) stdin() io.Reader {
+	if c.Stdin != nil {
+		return c.Stdin
+	}
+
+	return os.Stdin
+}
+
+func defaultSleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+type exitRequestError struct {
+	Code int
+}
+
+func (e *exitRequestError) Error() string {
+	return fmt.Sprintf("exit requested with code %d", e.Code)
+}
+
+func executeCommand(ctx context.Context, cfg Config, command string, args []string, timeout time.Duration, envVars []string, stdinContent []byte) (commandResult, []byte, []byte) {
+	if _, err := exec.LookPath(command); err != nil {
+		return commandResult{
+			Status:   statusNotFound,
+			ExitCode: ExitCodeCommandNotFound,
+		}, nil, nil
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	var stdoutBuffer, stderrBuffer bytes.Buffer
+
+	if cfg.HoldStdout {
+		cmd.Stdout = &stdoutBuffer
+	} else {
+		cmd.Stdout = cfg.stdout()
+	}
+
+	if cfg.HoldStderr {
+		cmd.Stderr = &stderrBuffer
+	} else {
+		cmd.Stderr = cfg.stderr()
+	}
+
+	if stdinContent == nil {
+		cmd.Stdin = cfg.stdin()
+	} else {
+		cmd.Stdin = bytes.NewReader(stdinContent)
+	}
+
+	cmd.Env = append(os.Environ(), envVars...)
+
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return commandResult{
+				Status:   statusTimeout,
+				ExitCode: ExitCodeTimeout,
+			}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return commandResult{
+				Status:   statusFinished,
+				ExitCode: exitErr.ExitCode(),
+			}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+		}
+
+		return commandResult{
+			Status:   statusUnknownError,
+			ExitCode: ExitCodeError,
+		}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+	}
+
+	return commandResult{
+		Status:   statusFinished,
+		ExitCode: cmd.ProcessState.ExitCode(),
+	}, stdoutBuffer.Bytes(), stderrBuffer.Bytes()
+}
+
+func fib(n int) float64 {
+	nf := float64(n)
+
+	return math.Round((math.Pow(math.Phi, nf) - math.Pow(-math.Phi, -nf)) * invSqrt5)
+}
+
+// FormatDuration renders a duration the way recur's verbose log lines and
+// text report do: rounded, and with all-zero trailing units dropped.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	if d > time.Second {
+		//nolint:mnd
+		d = d.Round(100 * time.Millisecond)
+	}
+
+	zeroUnits := regexp.MustCompile("(^|[^0-9])(?:0h)?(?:0m)?(?:0s)?$")
+	s := zeroUnits.ReplaceAllString(d.String(), "$1")
+
+	if s == "" {
+		return "0"
+	}
+
+	return s
+}
+
+const (
+	verboseLevelAttemptResults   = 1
+	verboseLevelConditionDetails = 2
+)
+
+// Run drives the retry loop: it runs cfg.Command, evaluates the success
+// condition, sleeps according to the configured backoff/jitter, and
+// repeats until the condition succeeds, an explicit exit() is requested,
+// the condition returns a stop reason, a circuit breaker trips, or
+// MaxAttempts is reached. The returned Stats.ExitCode is what a CLI
+// wrapper should pass to os.Exit.
+func Run(ctx context.Context, cfg Config) (Stats, error) {
+	var stats Stats
+	var cmdResult commandResult
+	var stdoutContent, stderrContent []byte
+	var startTime time.Time
+	var totalTime time.Duration
+	var history []Attempt
+
+	stats.ExitCodes = make([]int, 0)
+	stats.WaitTimes = make([]time.Duration, 0)
+	stats.CommandFound = make([]bool, 0)
+	stats.ConditionResults = make([]bool, 0)
+
+	sleep := cfg.Sleep
+	if sleep == nil {
+		sleep = defaultSleep
+	}
+
+	rng := newRand(cfg)
+	seedStrategies(cfg.DelayStrategies, rng)
+
+	var stdinContent []byte
+	if cfg.ReplayStdin {
+		data, err := io.ReadAll(cfg.stdin())
+		if err != nil {
+			return stats, fmt.Errorf("failed to read stdin: %w", err)
+		}
+
+		stdinContent = data
+	}
+
+	resetAttemptNum := 1
+	var delayOverride *time.Duration
+	var consecutiveFailures int
+	var failureTimes []time.Time
+
+	for attemptNum := 1; cfg.MaxAttempts < 0 || attemptNum <= cfg.MaxAttempts; attemptNum++ {
+		attemptSinceReset := attemptNum - resetAttemptNum + 1
+
+		var delay time.Duration
+		switch {
+		case delayOverride != nil:
+			delay = *delayOverride
+			delayOverride = nil
+		case attemptSinceReset > 1:
+			delay = computeDelay(Attempt{
+				MaxAttempts:      cfg.MaxAttempts,
+				Number:           attemptNum,
+				NumberSinceReset: attemptSinceReset,
+			}, cfg.DelayStrategies)
+		}
+
+		stats.WaitTimes = append(stats.WaitTimes, delay)
+
+		if delay > 0 {
+			if cfg.Verbose >= verboseLevelAttemptResults {
+				log.Printf("waiting %s after attempt %d", FormatDuration(delay), attemptNum-1)
+			}
+
+			sleep(ctx, delay)
+		}
+
+		attemptStart := time.Now()
+		if startTime.IsZero() {
+			startTime = attemptStart
+		}
+
+		envVars := []string{
+			fmt.Sprintf("%s=%d", EnvVarAttempt, attemptNum),
+			fmt.Sprintf("%s=%d", EnvVarAttemptSinceReset, attemptSinceReset),
+			fmt.Sprintf("%s=%d", EnvVarMaxAttempts, cfg.MaxAttempts),
+		}
+		cmdResult, stdoutContent, stderrContent = executeCommand(ctx, cfg, cfg.Command, cfg.Args, cfg.Timeout, envVars, stdinContent)
+
+		attemptEnd := time.Now()
+		attemptDuration := attemptEnd.Sub(attemptStart)
+		totalTime = attemptEnd.Sub(startTime)
+		stats.TotalTime = totalTime
+
+		stats.ExitCodes = append(stats.ExitCodes, cmdResult.ExitCode)
+		stats.CommandFound = append(stats.CommandFound, cmdResult.Status != statusNotFound)
+		stats.LastStdout = stdoutContent
+		stats.LastStderr = stderrContent
+
+		if cfg.Reset >= 0 && attemptDuration >= cfg.Reset {
+			resetAttemptNum = attemptNum
+		}
+
+		if cfg.Verbose >= verboseLevelAttemptResults {
+			switch cmdResult.Status {
+			case statusFinished:
+				log.Printf("command exited with code %d on attempt %d", cmdResult.ExitCode, attemptNum)
+			case statusTimeout:
+				log.Printf("command timed out after %s on attempt %d", FormatDuration(attemptDuration), attemptNum)
+			case statusNotFound:
+				log.Printf("command was not found on attempt %d", attemptNum)
+			case statusUnknownError:
+				log.Printf("unknown error occurred on attempt %d", attemptNum)
+			}
+		}
+
+		attemptInfo := Attempt{
+			CommandFound:     cmdResult.Status != statusNotFound,
+			Duration:         attemptDuration,
+			EndedAt:          attemptEnd,
+			ExitCode:         cmdResult.ExitCode,
+			MaxAttempts:      cfg.MaxAttempts,
+			Number:           attemptNum,
+			NumberSinceReset: attemptSinceReset,
+			StartedAt:        attemptStart,
+			StderrBytes:      len(stderrContent),
+			StderrSnippet:    stderrSnippet(stderrContent),
+			StdoutBytes:      len(stdoutContent),
+			TimedOut:         cmdResult.Status == statusTimeout,
+			TotalTime:        totalTime,
+		}
+
+		var evalResult conditionEvalResult
+		var err error
+
+		if cfg.ConditionProgram != nil {
+			evalResult, err = evaluateConditionFile(cfg.ConditionProgram, attemptInfo, history, stdinContent, stdoutContent, stderrContent, cfg.ReplayStdin, cfg.HoldStdout, cfg.HoldStderr)
+		} else {
+			evalResult, err = evaluateCondition(attemptInfo, cfg.Condition, stdinContent, stdoutContent, stderrContent, cfg.ReplayStdin, cfg.HoldStdout, cfg.HoldStderr, cfg.StarlarkModules)
+		}
+
+		history = append(history, attemptInfo)
+
+		if evalResult.FlushStdout {
+			cfg.stdout().Write(stdoutContent)
+		}
+
+		if evalResult.FlushStderr {
+			cfg.stderr().Write(stderrContent)
+		}
+
+		stats.Attempts = attemptNum
+
+		if err != nil {
+			var exitErr *exitRequestError
+			if errors.As(err, &exitErr) {
+				stats.ExitCode = exitErr.Code
+
+				return stats, nil
+			}
+
+			stats.ExitCode = 1
+
+			return stats, fmt.Errorf("condition evaluation failed: %w", err)
+		}
+
+		stats.ConditionResults = append(stats.ConditionResults, evalResult.Success)
+
+		if cfg.OnAttempt != nil {
+			cfg.OnAttempt(attemptInfo, evalResult.Success, delay)
+		}
+
+		if evalResult.Success {
+			stats.Successes++
+			stats.ExitCode = cmdResult.ExitCode
+
+			return stats, nil
+		}
+
+		stats.Failures++
+		delayOverride = evalResult.Delay
+
+		if evalResult.StopReason != "" {
+			stats.ExitCode = cmdResult.ExitCode
+
+			log.Printf("retry stopped: %s", evalResult.StopReason)
+
+			return stats, nil
+		}
+
+		consecutiveFailures++
+
+		if cfg.BreakerConsecutive > 0 && consecutiveFailures >= cfg.BreakerConsecutive {
+			stats.BreakerTripped = true
+			stats.BreakerTrippedAt = attemptNum
+			stats.ExitCode = ExitCodeBreakerTripped
+
+			return stats, fmt.Errorf("circuit breaker tripped: %d consecutive failures", consecutiveFailures)
+		}
+
+		if cfg.BreakerWindow > 0 && cfg.BreakerMaxFailures > 0 {
+			failureTimes = append(failureTimes, attemptEnd)
+
+			cutoff := attemptEnd.Add(-cfg.BreakerWindow)
+			live := failureTimes[:0]
+
+			for _, t := range failureTimes {
+				if t.After(cutoff) {
+					live = append(live, t)
+				}
+			}
+
+			failureTimes = live
+
+			if len(failureTimes) > cfg.BreakerMaxFailures {
+				stats.BreakerTripped = true
+				stats.BreakerTrippedAt = attemptNum
+				stats.ExitCode = ExitCodeBreakerTripped
+
+				return stats, fmt.Errorf("circuit breaker tripped: %d failures within %s", len(failureTimes), FormatDuration(cfg.BreakerWindow))
+			}
+		}
+
+		if cfg.Verbose >= verboseLevelConditionDetails {
+			log.Printf("condition not met; continuing to next attempt")
+		}
+	}
+
+	stats.TotalTime = totalTime
+	stats.ExitCode = cmdResult.ExitCode
+
+	return stats, fmt.Errorf("maximum %d attempts reached", cfg.MaxAttempts)
+}
+
+func newRand(cfg Config) *rand.Rand {
+	if cfg.RandSource != nil {
+		return rand.New(cfg.RandSource)
+	}
+
+	var pcg *rand.PCG
+	//nolint:gosec
+	if cfg.RandomSeed == 0 {
+		pcg = rand.NewPCG(rand.Uint64(), rand.Uint64())
+	} else {
+		pcg = rand.NewPCG(cfg.RandomSeed, 0)
+	}
+
+	return rand.New(pcg)
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recur
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunEmbedded exercises Run the way an embedder would: no shelling out
+// to the recur binary, just a direct call with the library's own Config.
+func TestRunEmbedded(t *testing.T) {
+	stats, err := Run(context.Background(), Config{
+		Command:     "true",
+		Condition:   "code == 0",
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if stats.Successes != 1 || stats.Attempts != 1 {
+		t.Errorf("expected one successful attempt, got %+v", stats)
+	}
+}
+
+func TestRunMaxAttemptsReached(t *testing.T) {
+	_, err := Run(context.Background(), Config{
+		Command:     "false",
+		Condition:   "code == 0",
+		MaxAttempts: 2,
+	})
+	if err == nil {
+		t.Error("expected an error once the attempt budget is exhausted")
+	}
+}
+
+// TestRunCustomIO covers the pluggable I/O hooks the request asked for:
+// an embedder can capture a supervised command's output instead of letting
+// it inherit the host process's standard streams.
+func TestRunCustomIO(t *testing.T) {
+	var stdout bytes.Buffer
+
+	stats, err := Run(context.Background(), Config{
+		Command:     "echo",
+		Args:        []string{"hi"},
+		Condition:   "code == 0",
+		MaxAttempts: 1,
+		Stdout:      &stdout,
+	})
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if stats.Successes != 1 {
+		t.Errorf("expected the command to succeed, got %+v", stats)
+	}
+
+	if stdout.String() != "hi\n" {
+		t.Errorf("expected captured stdout %q, got %q", "hi\n", stdout.String())
+	}
+}
+
+// TestRunConditionStopReason checks that a condition returning
+// {"stop": "..."} ends the retry loop without an error, the way an
+// explicit exit() does.
+func TestRunConditionStopReason(t *testing.T) {
+	stats, err := Run(context.Background(), Config{
+		Command:     "false",
+		Condition:   `{"success": False, "stop": "giving up"}`,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if stats.Attempts != 1 {
+		t.Errorf("expected the stop reason to end retrying after one attempt, got %+v", stats)
+	}
+}
+
+// TestRunConditionDelayOverride checks that a condition returning
+// {"delay": "..."} overrides the configured backoff for the next wait.
+func TestRunConditionDelayOverride(t *testing.T) {
+	var waitTimes []time.Duration
+
+	stats, err := Run(context.Background(), Config{
+		Command:   "false",
+		Condition: `{"success": False, "delay": "10ms"}`,
+		DelayStrategies: []DelayStrategy{
+			NewConstantStrategy(time.Second),
+		},
+		MaxAttempts: 2,
+		Sleep: func(ctx context.Context, d time.Duration) {
+			waitTimes = append(waitTimes, d)
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error once the attempt budget is exhausted")
+	}
+
+	if len(waitTimes) != 1 || waitTimes[0] != 10*time.Millisecond {
+		t.Errorf("expected the condition's delay to override the configured strategy, got %+v (stats: %+v)", waitTimes, stats)
+	}
+}
+
+// TestRunOnAttempt checks that Config.OnAttempt fires once per attempt,
+// in order, the way a streaming report sink needs it to.
+func TestRunOnAttempt(t *testing.T) {
+	var seen []int
+
+	_, err := Run(context.Background(), Config{
+		Command:     "false",
+		Condition:   "code == 0",
+		MaxAttempts: 3,
+		OnAttempt: func(attempt Attempt, success bool, wait time.Duration) {
+			seen = append(seen, attempt.Number)
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error once the attempt budget is exhausted")
+	}
+
+	if len(seen) != 3 || seen[0] != 1 || seen[1] != 2 || seen[2] != 3 {
+		t.Errorf("expected OnAttempt to fire for attempts 1, 2, 3 in order, got %v", seen)
+	}
+}
+
+// TestRunBreakerConsecutive checks that BreakerConsecutive aborts the
+// loop early, before MaxAttempts is reached, and records the trip.
+func TestRunBreakerConsecutive(t *testing.T) {
+	stats, err := Run(context.Background(), Config{
+		Command:            "false",
+		Condition:          "code == 0",
+		BreakerConsecutive: 2,
+		MaxAttempts:        10,
+	})
+	if err == nil {
+		t.Fatal("expected the circuit breaker to abort with an error")
+	}
+
+	if !stats.BreakerTripped || stats.BreakerTrippedAt != 2 {
+		t.Errorf("expected the breaker to trip at attempt 2, got %+v", stats)
+	}
+
+	if stats.ExitCode != ExitCodeBreakerTripped {
+		t.Errorf("expected exit code %d, got %d", ExitCodeBreakerTripped, stats.ExitCode)
+	}
+}
+
+// TestRunBreakerWindow checks that a rolling-window breaker trips once more
+// than BreakerMaxFailures failures fall inside BreakerWindow.
+func TestRunBreakerWindow(t *testing.T) {
+	stats, err := Run(context.Background(), Config{
+		Command:            "false",
+		Condition:          "code == 0",
+		BreakerMaxFailures: 2,
+		BreakerWindow:      time.Hour,
+		MaxAttempts:        10,
+	})
+	if err == nil {
+		t.Fatal("expected the circuit breaker to abort with an error")
+	}
+
+	if !stats.BreakerTripped || stats.BreakerTrippedAt != 3 {
+		t.Errorf("expected the breaker to trip at attempt 3, got %+v", stats)
+	}
+}
+
+// TestComputeDelayChain checks that DelayStrategy values compose left to
+// right, the way -b/-F/-m desugar into a constant+exponential+cap chain.
+func TestComputeDelayChain(t *testing.T) {
+	strategies := []DelayStrategy{
+		NewConstantStrategy(100 * time.Millisecond),
+		NewExponentialStrategy(2 * time.Second),
+		NewCapStrategy(time.Second),
+	}
+
+	delay := computeDelay(Attempt{NumberSinceReset: 3}, strategies)
+
+	if delay != time.Second {
+		t.Errorf("expected the cap to clip the delay to 1s, got %s", delay)
+	}
+}
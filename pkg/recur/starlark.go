@@ -0,0 +1,765 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recur
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Predeclared is the set of globals available both to condition
+// expressions and to condition files: exit(), inspect(), and the curated
+// standard library from ResolveModule. It's exported so "recur repl" can
+// build the exact same environment evaluateCondition does.
+func Predeclared() starlark.StringDict {
+	return starlark.StringDict{
+		"exit":     starlark.NewBuiltin("exit", StarlarkExit),
+		"inspect":  starlark.NewBuiltin("inspect", StarlarkInspect),
+		"hash":     hashModule,
+		"json":     starlarkjson.Module,
+		"jsonpath": starlark.NewBuiltin("jsonpath", starlarkJSONPath),
+		"math":     starlarkmath.Module,
+		"re":       reModule,
+		"time_lib": starlarktime.Module,
+	}
+}
+
+const (
+	starlarkVarFlushStderr = "_flush_stderr"
+	starlarkVarFlushStdout = "_flush_stdout"
+)
+
+// reModule is a hand-rolled stand-in for the regexp half of qri-io/starlib:
+// go.starlark.net doesn't ship one, and a curated wrapper around Go's
+// regexp is enough for the matching recur's conditions need.
+var reModule = &starlarkstruct.Module{
+	Name: "re",
+	Members: starlark.StringDict{
+		"match":  starlark.NewBuiltin("re.match", starlarkReMatch),
+		"search": starlark.NewBuiltin("re.search", starlarkReSearch),
+	},
+}
+
+// hashModule covers the other module the request asks for that
+// go.starlark.net doesn't provide out of the box.
+var hashModule = &starlarkstruct.Module{
+	Name: "hash",
+	Members: starlark.StringDict{
+		"md5": starlark.NewBuiltin("hash.md5", starlarkHashFunc(func(b []byte) []byte {
+			sum := md5.Sum(b)
+
+			return sum[:]
+		})),
+		"sha1": starlark.NewBuiltin("hash.sha1", starlarkHashFunc(func(b []byte) []byte {
+			sum := sha1.Sum(b)
+
+			return sum[:]
+		})),
+		"sha256": starlark.NewBuiltin("hash.sha256", starlarkHashFunc(func(b []byte) []byte {
+			sum := sha256.Sum256(b)
+
+			return sum[:]
+		})),
+	},
+}
+
+func starlarkReMatch(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return starlarkReFind(b, args, kwargs, true)
+}
+
+func starlarkReSearch(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return starlarkReFind(b, args, kwargs, false)
+}
+
+func starlarkReFind(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, anchored bool) (starlark.Value, error) {
+	var pattern, text starlark.String
+
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "text", &text); err != nil {
+		return nil, err
+	}
+
+	patternStr := pattern.GoString()
+	if anchored && !strings.HasPrefix(patternStr, "^") {
+		patternStr = "^" + patternStr
+	}
+
+	re, err := regexp.Compile(patternStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp pattern: %w", err)
+	}
+
+	match := re.FindString(text.GoString())
+	if match == "" && !re.MatchString(text.GoString()) {
+		return starlark.None, nil
+	}
+
+	return starlark.String(match), nil
+}
+
+// starlarkHashFunc adapts a Go hash sum function to a Starlark builtin
+// returning a hex digest, so hash.md5/sha1/sha256 share one shape despite
+// returning different-sized arrays.
+func starlarkHashFunc(sum func([]byte) []byte) func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var text starlark.String
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "text", &text); err != nil {
+			return nil, err
+		}
+
+		digest := sum([]byte(text.GoString()))
+
+		return starlark.String(hex.EncodeToString(digest)), nil
+	}
+}
+
+// ResolveModule resolves a load() path to a predeclared module or, failing
+// that, a ".star" file found on searchPath. It's exported so "recur repl"
+// can wire up the same thread.Load as conditions and condition files.
+func ResolveModule(searchPath []string, module string) (starlark.StringDict, error) {
+	switch module {
+	case "json.star":
+		return starlark.StringDict{"json": starlarkjson.Module}, nil
+
+	case "re.star":
+		return starlark.StringDict{"re": reModule}, nil
+
+	case "time.star":
+		return starlark.StringDict{"time": starlarktime.Module}, nil
+
+	case "hash.star":
+		return starlark.StringDict{"hash": hashModule}, nil
+
+	case "math.star":
+		return starlark.StringDict{"math": starlarkmath.Module}, nil
+	}
+
+	for _, dir := range searchPath {
+		path := filepath.Join(dir, module)
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		thread := &starlark.Thread{
+			Name: module,
+			Load: func(thread *starlark.Thread, nested string) (starlark.StringDict, error) {
+				return ResolveModule(searchPath, nested)
+			},
+		}
+
+		return starlark.ExecFileOptions(syntax.LegacyFileOptions(), thread, path, src, nil)
+	}
+
+	return nil, fmt.Errorf("module not found: %s", module)
+}
+
+type conditionEvalResult struct {
+	Success     bool
+	FlushStdout bool
+	FlushStderr bool
+
+	// Delay overrides the next attempt's wait time when set, letting a
+	// condition implement adaptive backoff (e.g. from a Retry-After
+	// header) instead of following the configured DelayStrategies.
+	Delay *time.Duration
+
+	// StopReason, when non-empty, halts retrying immediately: Run logs
+	// it and returns with the last attempt's exit code, the same as
+	// exhausting MaxAttempts but without treating it as an error.
+	StopReason string
+}
+
+// parseConditionResult interprets a condition's return value. A bool
+// means success/failure as before. A dict lets a condition request
+// adaptive retry: {"success": False, "delay": "12s", "stop": "auth failed"}.
+func parseConditionResult(val starlark.Value) (success bool, delay *time.Duration, stopReason string, err error) {
+	dict, ok := val.(*starlark.Dict)
+	if !ok {
+		return bool(val.Truth()), nil, "", nil
+	}
+
+	if v, found, _ := dict.Get(starlark.String("success")); found {
+		success = bool(v.Truth())
+	}
+
+	if v, found, _ := dict.Get(starlark.String("delay")); found {
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return false, nil, "", fmt.Errorf(`condition result "delay" must be a string`)
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return false, nil, "", fmt.Errorf("condition result %q: %w", "delay", err)
+		}
+
+		delay = &d
+	}
+
+	if v, found, _ := dict.Get(starlark.String("stop")); found {
+		s, ok := starlark.AsString(v)
+		if !ok {
+			return false, nil, "", fmt.Errorf(`condition result "stop" must be a string`)
+		}
+
+		stopReason = s
+	}
+
+	return success, delay, stopReason, nil
+}
+
+func StarlarkExit(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var code starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &code); err != nil {
+		return nil, err
+	}
+
+	if _, ok := code.(starlark.NoneType); ok {
+		return starlark.None, &exitRequestError{Code: int(ExitCodeCommandNotFound)}
+	}
+
+	if codeInt, ok := code.(starlark.Int); ok {
+		exitCode, ok := codeInt.Int64()
+		if !ok {
+			return nil, fmt.Errorf("exit code too large")
+		}
+
+		return starlark.None, &exitRequestError{Code: int(exitCode)}
+	}
+
+	return nil, fmt.Errorf("exit code wasn't 'int' or 'None'")
+}
+
+func StarlarkInspect(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var prefix starlark.String
+	var value starlark.Value
+
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "value", &value, "prefix?", &prefix); err != nil {
+		return nil, err
+	}
+
+	prefixStr := ""
+	if prefix.Len() > 0 {
+		prefixStr = prefix.GoString()
+	}
+
+	log.Printf("inspect: %s%v\n", prefixStr, value)
+
+	return value, nil
+}
+
+// starlarkIOBuffer is a starlark.Value that represents a buffer
+// (like stdin or stdout) and provides methods to interact with it.
+type starlarkIOBuffer struct {
+	methods starlark.StringDict
+}
+
+// String returns the string representation of the buffer.
+func (b *starlarkIOBuffer) String() string { return "<io_buffer>" }
+
+// Type returns the type of the value.
+func (b *starlarkIOBuffer) Type() string { return "io_buffer" }
+
+// Freeze makes the value immutable.
+func (b *starlarkIOBuffer) Freeze() {}
+
+// Truth returns the truth value of the buffer.
+func (b *starlarkIOBuffer) Truth() starlark.Bool { return starlark.True }
+
+// Hash returns a hash value for the buffer.
+func (b *starlarkIOBuffer) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %s", b.Type())
+}
+
+// Attr returns the value of a field or method.
+func (b *starlarkIOBuffer) Attr(name string) (starlark.Value, error) {
+	if val, ok := b.methods[name]; ok {
+		return val, nil
+	}
+
+	// starlark.NoSuchAttrError is handled by Starlark.
+	return nil, nil
+}
+
+// AttrNames returns a list of attribute names.
+func (b *starlarkIOBuffer) AttrNames() []string {
+	names := make([]string, 0, len(b.methods))
+
+	for name := range b.methods {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func flushLocal(thread *starlark.Thread, varName string) bool {
+	if v := thread.Local(varName); v != nil {
+		if flushVal, ok := v.(starlark.Value); ok {
+			return flushVal == starlark.True
+		}
+	}
+
+	return false
+}
+
+func makeFlushMethod(varName string) *starlark.Builtin {
+	return starlark.NewBuiltin("flush", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+			return nil, err
+		}
+
+		thread.SetLocal(varName, starlark.True)
+
+		return starlark.None, nil
+	})
+}
+
+func makeReadMethod(content []byte) *starlark.Builtin {
+	return starlark.NewBuiltin("read", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+			return nil, err
+		}
+
+		return starlark.String(string(content)), nil
+	})
+}
+
+func makeSearchMethod(content []byte) *starlark.Builtin {
+	return starlark.NewBuiltin("search", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var pattern starlark.String
+		var group starlark.Value = starlark.None
+		var defaultValue starlark.Value = starlark.None
+
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "group?", &group, "default?", &defaultValue); err != nil {
+			return nil, err
+		}
+
+		if content == nil {
+			return defaultValue, nil
+		}
+
+		re, err := regexp.Compile(pattern.GoString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp pattern: %w", err)
+		}
+
+		matches := re.FindSubmatch(content)
+		if matches == nil {
+			return defaultValue, nil
+		}
+
+		// If group is not specified, return the list of matches.
+		if _, ok := group.(starlark.NoneType); ok {
+			starlarkMatches := make([]starlark.Value, len(matches))
+
+			for i, match := range matches {
+				if match == nil {
+					starlarkMatches[i] = starlark.None
+				} else {
+					starlarkMatches[i] = starlark.String(string(match))
+				}
+			}
+
+			return starlark.NewList(starlarkMatches), nil
+		}
+
+		// If group is specified, return the specified group.
+		groupInt, ok := group.(starlark.Int)
+		if !ok {
+			return nil, fmt.Errorf("group must be an integer")
+		}
+
+		groupIndex, ok := groupInt.Int64()
+		if !ok {
+			return nil, fmt.Errorf("group index too large")
+		}
+
+		if groupIndex < 0 || groupIndex >= int64(len(matches)) {
+			return defaultValue, nil
+		}
+
+		match := matches[groupIndex]
+		if match == nil {
+			return defaultValue, nil
+		}
+
+		return starlark.String(string(match)), nil
+	})
+}
+
+// makeLinesMethod splits content into a list of lines, without a trailing
+// empty line for a final newline, so conditions can scan output line by
+// line (e.g. for a Retry-After header) without hand-rolling a split on
+// "\n" themselves.
+func makeLinesMethod(content []byte) *starlark.Builtin {
+	return starlark.NewBuiltin("lines", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+			return nil, err
+		}
+
+		if len(content) == 0 {
+			return starlark.NewList(nil), nil
+		}
+
+		rawLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+		lines := make([]starlark.Value, len(rawLines))
+
+		for i, line := range rawLines {
+			lines[i] = starlark.String(line)
+		}
+
+		return starlark.NewList(lines), nil
+	})
+}
+
+// goValueToStarlark converts a Go value produced by encoding/json.Unmarshal
+// (map[string]any, []any, string, float64, bool, nil) into the equivalent
+// Starlark value, the way stdout.json()/jsonpath() hand JSON back to a
+// condition.
+func goValueToStarlark(v any) (starlark.Value, error) {
+	switch typed := v.(type) {
+	case nil:
+		return starlark.None, nil
+
+	case bool:
+		return starlark.Bool(typed), nil
+
+	case float64:
+		return starlark.Float(typed), nil
+
+	case string:
+		return starlark.String(typed), nil
+
+	case []any:
+		elems := make([]starlark.Value, len(typed))
+
+		for i, elem := range typed {
+			val, err := goValueToStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			elems[i] = val
+		}
+
+		return starlark.NewList(elems), nil
+
+	case map[string]any:
+		dict := starlark.NewDict(len(typed))
+
+		for key, elem := range typed {
+			val, err := goValueToStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := dict.SetKey(starlark.String(key), val); err != nil {
+				return nil, err
+			}
+		}
+
+		return dict, nil
+
+	default:
+		return nil, fmt.Errorf("jsonpath: unsupported JSON value type %T", v)
+	}
+}
+
+// makeJSONMethod wraps jsonPathEval as a buffer method, so conditions can
+// scrape a captured stream with e.g. stdout.json("$.retry_after", default=0)
+// instead of parsing JSON by hand.
+func makeJSONMethod(content []byte) *starlark.Builtin {
+	return starlark.NewBuiltin("json", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var path starlark.String
+		var defaultValue starlark.Value = starlark.None
+
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &path, "default?", &defaultValue); err != nil {
+			return nil, err
+		}
+
+		if content == nil {
+			return defaultValue, nil
+		}
+
+		value, found, err := jsonPathEval(content, path.GoString())
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return defaultValue, nil
+		}
+
+		return goValueToStarlark(value)
+	})
+}
+
+// starlarkJSONPath is the top-level jsonpath(text, expr, default=) builtin,
+// for scraping ad hoc strings that aren't one of stdin/stdout/stderr.
+func starlarkJSONPath(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text, expr starlark.String
+	var defaultValue starlark.Value = starlark.None
+
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "text", &text, "expr", &expr, "default?", &defaultValue); err != nil {
+		return nil, err
+	}
+
+	value, found, err := jsonPathEval([]byte(text.GoString()), expr.GoString())
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return defaultValue, nil
+	}
+
+	return goValueToStarlark(value)
+}
+
+// ConditionBuffers builds the stdin/stdout/stderr Starlark values shared
+// by condition expressions, condition files, and "recur repl".
+func ConditionBuffers(stdinContent []byte, stdoutContent []byte, stderrContent []byte, replayStdin bool, holdStdout bool, holdStderr bool) (stdin starlark.Value, stdout starlark.Value, stderr starlark.Value) {
+	if replayStdin {
+		stdin = &starlarkIOBuffer{
+			methods: starlark.StringDict{
+				"json":   makeJSONMethod(stdinContent),
+				"read":   makeReadMethod(stdinContent),
+				"search": makeSearchMethod(stdinContent),
+			},
+		}
+	} else {
+		stdin = starlark.None
+	}
+
+	if holdStdout {
+		stdout = &starlarkIOBuffer{
+			methods: starlark.StringDict{
+				"flush":  makeFlushMethod(starlarkVarFlushStdout),
+				"json":   makeJSONMethod(stdoutContent),
+				"lines":  makeLinesMethod(stdoutContent),
+				"read":   makeReadMethod(stdoutContent),
+				"search": makeSearchMethod(stdoutContent),
+			},
+		}
+	} else {
+		stdout = starlark.None
+	}
+
+	if holdStderr {
+		stderr = &starlarkIOBuffer{
+			methods: starlark.StringDict{
+				"flush":  makeFlushMethod(starlarkVarFlushStderr),
+				"json":   makeJSONMethod(stderrContent),
+				"lines":  makeLinesMethod(stderrContent),
+				"read":   makeReadMethod(stderrContent),
+				"search": makeSearchMethod(stderrContent),
+			},
+		}
+	} else {
+		stderr = starlark.None
+	}
+
+	return stdin, stdout, stderr
+}
+
+func evaluateCondition(attemptInfo Attempt, expr string, stdinContent []byte, stdoutContent []byte, stderrContent []byte, replayStdin bool, holdStdout bool, holdStderr bool, modulePath []string) (conditionEvalResult, error) {
+	thread := &starlark.Thread{
+		Name: "condition",
+		Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+			return ResolveModule(modulePath, module)
+		},
+	}
+
+	var code starlark.Value
+	if attemptInfo.CommandFound {
+		code = starlark.MakeInt(attemptInfo.ExitCode)
+	} else {
+		code = starlark.None
+	}
+
+	stdin, stdout, stderr := ConditionBuffers(stdinContent, stdoutContent, stderrContent, replayStdin, holdStdout, holdStderr)
+
+	env := Predeclared()
+
+	env["attempt"] = starlark.MakeInt(attemptInfo.Number)
+	env["attempt_since_reset"] = starlark.MakeInt(attemptInfo.NumberSinceReset)
+	env["code"] = code
+	env["command_found"] = starlark.Bool(attemptInfo.CommandFound)
+	env["max_attempts"] = starlark.MakeInt(attemptInfo.MaxAttempts)
+	env["stderr"] = stderr
+	env["stdin"] = stdin
+	env["stdout"] = stdout
+	env["time"] = starlark.Float(float64(attemptInfo.Duration) / float64(time.Second))
+	env["total_time"] = starlark.Float(float64(attemptInfo.TotalTime) / float64(time.Second))
+
+	val, err := starlark.EvalOptions(syntax.LegacyFileOptions(), thread, "", expr, env)
+	flushStdout := flushLocal(thread, starlarkVarFlushStdout)
+	flushStderr := flushLocal(thread, starlarkVarFlushStderr)
+	if err != nil {
+		var exitErr *exitRequestError
+		if errors.As(err, &exitErr) {
+			return conditionEvalResult{
+				FlushStdout: flushStdout,
+				FlushStderr: flushStderr,
+			}, exitErr
+		}
+
+		return conditionEvalResult{}, err
+	}
+
+	success, delay, stopReason, err := parseConditionResult(val)
+	if err != nil {
+		return conditionEvalResult{}, err
+	}
+
+	flushStdout = flushStdout || success
+	flushStderr = flushStderr || success
+
+	return conditionEvalResult{
+		Success:     success,
+		FlushStdout: flushStdout,
+		FlushStderr: flushStderr,
+		Delay:       delay,
+		StopReason:  stopReason,
+	}, nil
+}
+
+// ConditionProgram is a condition file parsed and frozen once at startup;
+// only its should_retry entry point runs in the per-attempt hot loop.
+type ConditionProgram struct {
+	fn *starlark.Function
+}
+
+// LoadConditionFile parses a full Starlark file (resolving load(...)
+// against modulePath) and looks up its should_retry(ctx) entry point.
+func LoadConditionFile(path string, modulePath []string) (*ConditionProgram, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read condition file: %w", err)
+	}
+
+	thread := &starlark.Thread{
+		Name: "condition-file",
+		Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+			return ResolveModule(modulePath, module)
+		},
+	}
+
+	globals, err := starlark.ExecFileOptions(syntax.LegacyFileOptions(), thread, path, src, Predeclared())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse condition file: %w", err)
+	}
+
+	fn, ok := globals["should_retry"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("condition file %s must define should_retry(ctx)", path)
+	}
+
+	return &ConditionProgram{fn: fn}, nil
+}
+
+// evaluateConditionFile calls a condition file's should_retry(ctx) entry
+// point, passing a ctx struct with the same fields as the expression
+// environment plus a history of prior attempts.
+func evaluateConditionFile(program *ConditionProgram, attemptInfo Attempt, history []Attempt, stdinContent []byte, stdoutContent []byte, stderrContent []byte, replayStdin bool, holdStdout bool, holdStderr bool) (conditionEvalResult, error) {
+	thread := &starlark.Thread{Name: "condition"}
+
+	var code starlark.Value
+	if attemptInfo.CommandFound {
+		code = starlark.MakeInt(attemptInfo.ExitCode)
+	} else {
+		code = starlark.None
+	}
+
+	stdin, stdout, stderr := ConditionBuffers(stdinContent, stdoutContent, stderrContent, replayStdin, holdStdout, holdStderr)
+
+	historyValues := make([]starlark.Value, len(history))
+	for i, past := range history {
+		historyValues[i] = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"attempt": starlark.MakeInt(past.Number),
+			"code":    starlark.MakeInt(past.ExitCode),
+			"time":    starlark.Float(float64(past.Duration) / float64(time.Second)),
+		})
+	}
+
+	ctx := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"attempt":             starlark.MakeInt(attemptInfo.Number),
+		"attempt_since_reset": starlark.MakeInt(attemptInfo.NumberSinceReset),
+		"code":                code,
+		"command_found":       starlark.Bool(attemptInfo.CommandFound),
+		"history":             starlark.NewList(historyValues),
+		"max_attempts":        starlark.MakeInt(attemptInfo.MaxAttempts),
+		"stderr":              stderr,
+		"stdin":               stdin,
+		"stdout":              stdout,
+		"time":                starlark.Float(float64(attemptInfo.Duration) / float64(time.Second)),
+		"total_time":          starlark.Float(float64(attemptInfo.TotalTime) / float64(time.Second)),
+	})
+
+	val, err := starlark.Call(thread, program.fn, starlark.Tuple{ctx}, nil)
+	flushStdout := flushLocal(thread, starlarkVarFlushStdout)
+	flushStderr := flushLocal(thread, starlarkVarFlushStderr)
+	if err != nil {
+		var exitErr *exitRequestError
+		if errors.As(err, &exitErr) {
+			return conditionEvalResult{
+				FlushStdout: flushStdout,
+				FlushStderr: flushStderr,
+			}, exitErr
+		}
+
+		return conditionEvalResult{}, err
+	}
+
+	success, delay, stopReason, err := parseConditionResult(val)
+	if err != nil {
+		return conditionEvalResult{}, err
+	}
+
+	flushStdout = flushStdout || success
+	flushStderr = flushStderr || success
+
+	return conditionEvalResult{
+		Success:     success,
+		FlushStdout: flushStdout,
+		FlushStderr: flushStderr,
+		Delay:       delay,
+		StopReason:  stopReason,
+	}, nil
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recur
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// DelayStrategy computes one stage of the delay before the next attempt.
+// Next receives the attempt about to run (Duration, ExitCode, and
+// CommandFound aren't known yet and are zero) and the delay accumulated by
+// the previous strategy in the chain, zero for the first one. Run
+// evaluates Config.DelayStrategies left to right, feeding each strategy's
+// result to the next, so strategies compose: "exponential backoff, then
+// capped, then jittered" is three strategies run in that order.
+type DelayStrategy interface {
+	Next(attempt Attempt, prev time.Duration) time.Duration
+}
+
+// computeDelay runs strategies in order, starting from a zero delay.
+func computeDelay(attempt Attempt, strategies []DelayStrategy) time.Duration {
+	var delay time.Duration
+
+	for _, strategy := range strategies {
+		delay = strategy.Next(attempt, delay)
+	}
+
+	return delay
+}
+
+// randSeeded is implemented by strategies whose Next needs randomness.
+// Run seeds them all from the same *rand.Rand it derives from
+// Config.RandomSeed/RandSource, so -s/--seed reproducibility covers
+// strategy chains the same way it already covers the legacy jitter flag.
+type randSeeded interface {
+	seedRand(rng *rand.Rand)
+}
+
+func seedStrategies(strategies []DelayStrategy, rng *rand.Rand) {
+	for _, strategy := range strategies {
+		if s, ok := strategy.(randSeeded); ok {
+			s.seedRand(rng)
+		}
+	}
+}
+
+// ConstantStrategy adds a fixed delay, the same role -d/--delay has always
+// played.
+type ConstantStrategy struct {
+	Delay time.Duration
+}
+
+func NewConstantStrategy(delay time.Duration) *ConstantStrategy {
+	return &ConstantStrategy{Delay: delay}
+}
+
+func (s *ConstantStrategy) Next(_ Attempt, prev time.Duration) time.Duration {
+	return prev + s.Delay
+}
+
+// ExponentialStrategy adds Base raised to the power of the
+// attempt-since-reset number minus one, the formula -b/--backoff has always used.
+type ExponentialStrategy struct {
+	Base time.Duration
+}
+
+func NewExponentialStrategy(base time.Duration) *ExponentialStrategy {
+	return &ExponentialStrategy{Base: base}
+}
+
+func (s *ExponentialStrategy) Next(attempt Attempt, prev time.Duration) time.Duration {
+	return prev + secondsToDuration(math.Pow(s.Base.Seconds(), float64(attempt.NumberSinceReset-1)))
+}
+
+// FibonacciStrategy adds the (attempt-since-reset minus one)'th Fibonacci
+// number of seconds, the formula -F/--fib has always used.
+type FibonacciStrategy struct{}
+
+func NewFibonacciStrategy() *FibonacciStrategy {
+	return &FibonacciStrategy{}
+}
+
+func (s *FibonacciStrategy) Next(attempt Attempt, prev time.Duration) time.Duration {
+	return prev + secondsToDuration(fib(attempt.NumberSinceReset-1))
+}
+
+// LinearStrategy adds Step multiplied by the attempt-since-reset number
+// minus one.
+type LinearStrategy struct {
+	Step time.Duration
+}
+
+func NewLinearStrategy(step time.Duration) *LinearStrategy {
+	return &LinearStrategy{Step: step}
+}
+
+func (s *LinearStrategy) Next(attempt Attempt, prev time.Duration) time.Duration {
+	return prev + time.Duration(attempt.NumberSinceReset-1)*s.Step
+}
+
+// UniformJitterStrategy adds a uniformly distributed random delay from
+// Interval, the formula -j/--jitter has always used.
+type UniformJitterStrategy struct {
+	Interval Interval
+
+	rng *rand.Rand
+}
+
+func NewUniformJitterStrategy(interval Interval) *UniformJitterStrategy {
+	return &UniformJitterStrategy{Interval: interval}
+}
+
+func (s *UniformJitterStrategy) seedRand(rng *rand.Rand) {
+	s.rng = rng
+}
+
+func (s *UniformJitterStrategy) Next(_ Attempt, prev time.Duration) time.Duration {
+	span := (s.Interval.End - s.Interval.Start).Seconds()
+
+	return prev + s.Interval.Start + secondsToDuration(fallbackRand(s.rng).Float64()*span)
+}
+
+// CapStrategy clamps the delay accumulated so far to at most Max, the role
+// -m/--max-delay has always played.
+type CapStrategy struct {
+	Max time.Duration
+}
+
+func NewCapStrategy(max time.Duration) *CapStrategy {
+	return &CapStrategy{Max: max}
+}
+
+func (s *CapStrategy) Next(_ Attempt, prev time.Duration) time.Duration {
+	if prev > s.Max {
+		return s.Max
+	}
+
+	return prev
+}
+
+// DecorrelatedStrategy implements AWS's decorrelated-jitter backoff:
+// sleep = min(Cap, uniform(Base, prevSleep*3)). Seeding the next range from
+// the delay it just produced, rather than from a fixed range like
+// UniformJitterStrategy, spreads out retries that started in lockstep
+// instead of letting them walk the same range together. Like -r/--reset,
+// the first delay of a series (a NumberSinceReset of 2 or less, since Run
+// only computes delays from the second attempt on) restarts prevSleep
+// from Base.
+//
+// Unlike the additive strategies above, DecorrelatedStrategy replaces prev
+// outright: it's meant to be a complete delay formula on its own, not one
+// stage of a longer chain.
+type DecorrelatedStrategy struct {
+	Base time.Duration
+	Cap  time.Duration
+
+	rng       *rand.Rand
+	prevSleep time.Duration
+}
+
+func NewDecorrelatedStrategy(base, capDelay time.Duration) *DecorrelatedStrategy {
+	return &DecorrelatedStrategy{Base: base, Cap: capDelay}
+}
+
+func (s *DecorrelatedStrategy) seedRand(rng *rand.Rand) {
+	s.rng = rng
+}
+
+func (s *DecorrelatedStrategy) Next(attempt Attempt, _ time.Duration) time.Duration {
+	if attempt.NumberSinceReset <= 2 {
+		s.prevSleep = 0
+	}
+
+	prevSleep := s.prevSleep
+	if prevSleep < s.Base {
+		prevSleep = s.Base
+	}
+
+	upper := prevSleep * 3 //nolint:mnd
+
+	delay := s.Base + secondsToDuration(fallbackRand(s.rng).Float64()*(upper-s.Base).Seconds())
+	if delay > s.Cap {
+		delay = s.Cap
+	}
+
+	s.prevSleep = delay
+
+	return delay
+}
+
+// fallbackRand returns rng, or an automatically seeded source if rng is
+// nil, i.e. the strategy is used directly instead of through Run (which
+// always seeds strategies via seedRand).
+func fallbackRand(rng *rand.Rand) *rand.Rand {
+	if rng != nil {
+		return rng
+	}
+
+	//nolint:gosec
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
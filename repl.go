@@ -0,0 +1,212 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.starlark.net/repl"
+	"go.starlark.net/starlark"
+
+	"github.com/dbohdan/recur/v2/pkg/recur"
+)
+
+const lastAttemptFilename = "last-attempt.json"
+
+// replFixture is the set of values "recur repl" seeds its globals from,
+// either read from a --fixture JSON file or cached from the last real run.
+type replFixture struct {
+	Attempt           int     `json:"attempt"`
+	AttemptSinceReset int     `json:"attempt_since_reset"`
+	Code              *int    `json:"code"`
+	CommandFound      bool    `json:"command_found"`
+	MaxAttempts       int     `json:"max_attempts"`
+	Stdin             string  `json:"stdin"`
+	Stdout            string  `json:"stdout"`
+	Stderr            string  `json:"stderr"`
+	Time              float64 `json:"time"`
+	TotalTime         float64 `json:"total_time"`
+}
+
+func syntheticFixture() replFixture {
+	code := 0
+
+	return replFixture{
+		Attempt:           1,
+		AttemptSinceReset: 1,
+		Code:              &code,
+		CommandFound:      true,
+		MaxAttempts:       maxAttemptsDefault,
+	}
+}
+
+// stateDir returns the directory recur caches repl fixtures under,
+// honoring $XDG_STATE_HOME like the rest of the XDG base directory spec.
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "recur"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "state", "recur"), nil
+}
+
+// saveLastAttempt caches the final attempt of a normal run so a later
+// "recur repl" can pick up where it left off without a --fixture file.
+func saveLastAttempt(config retryConfig, stats recur.Stats) error {
+	if stats.Attempts == 0 {
+		return nil
+	}
+
+	dir, err := stateDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	last := stats.Attempts - 1
+	code := stats.ExitCodes[last]
+
+	fixture := replFixture{
+		Attempt:           stats.Attempts,
+		AttemptSinceReset: stats.Attempts,
+		Code:              &code,
+		CommandFound:      stats.CommandFound[last],
+		MaxAttempts:       config.MaxAttempts,
+		Stdout:            string(stats.LastStdout),
+		Stderr:            string(stats.LastStderr),
+		TotalTime:         stats.TotalTime.Seconds(),
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal repl fixture: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(dir, lastAttemptFilename), data, 0o644)
+}
+
+func loadFixture(path string) (replFixture, error) {
+	if path == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return syntheticFixture(), nil
+		}
+
+		path = filepath.Join(dir, lastAttemptFilename)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return syntheticFixture(), nil
+		}
+
+		return replFixture{}, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture replFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return replFixture{}, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+
+	return fixture, nil
+}
+
+// runRepl implements the "recur repl" subcommand: an interactive Starlark
+// session with the same globals recur.Run builds for a condition,
+// prepopulated from a fixture.
+func runRepl(args []string) int {
+	var fixturePath string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--fixture":
+			i++
+
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: no value for option: --fixture")
+
+				return exitCodeBadUsage
+			}
+
+			fixturePath = args[i]
+
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown option: %v\n", args[i])
+
+			return exitCodeBadUsage
+		}
+	}
+
+	fixture, err := loadFixture(fixturePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		return exitCodeError
+	}
+
+	var code starlark.Value
+	if fixture.Code != nil {
+		code = starlark.MakeInt(*fixture.Code)
+	} else {
+		code = starlark.None
+	}
+
+	stdin, stdout, stderr := recur.ConditionBuffers(
+		[]byte(fixture.Stdin), []byte(fixture.Stdout), []byte(fixture.Stderr),
+		true, true, true,
+	)
+
+	globals := recur.Predeclared()
+
+	globals["attempt"] = starlark.MakeInt(fixture.Attempt)
+	globals["attempt_since_reset"] = starlark.MakeInt(fixture.AttemptSinceReset)
+	globals["code"] = code
+	globals["command_found"] = starlark.Bool(fixture.CommandFound)
+	globals["max_attempts"] = starlark.MakeInt(fixture.MaxAttempts)
+	globals["stderr"] = stderr
+	globals["stdin"] = stdin
+	globals["stdout"] = stdout
+	globals["time"] = starlark.Float(fixture.Time)
+	globals["total_time"] = starlark.Float(fixture.TotalTime)
+
+	thread := &starlark.Thread{
+		Name: "repl",
+		Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+			return recur.ResolveModule(nil, module)
+		},
+	}
+
+	repl.REPL(thread, globals)
+
+	return 0
+}
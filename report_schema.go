@@ -0,0 +1,11 @@
+package main
+
+import _ "embed"
+
+// reportSchemaJSON is the JSON Schema (draft 2020-12) describing every
+// field the "json:" report format emits. It's the payload for
+// "recur --print-report-schema" and is validated against in
+// main_test.go to catch accidental field renames or type drift.
+//
+//go:embed report_schema.json
+var reportSchemaJSON string
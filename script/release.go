@@ -14,6 +14,17 @@ const (
 	checksumFilename = "SHA512SUMS.txt"
 	projectName      = "recur"
 	distDir          = "dist"
+
+	// envVarSigningKey points at an SSH private key used to sign
+	// SHA512SUMS.txt. Signing is skipped when it's unset.
+	envVarSigningKey = "RECUR_RELEASE_SIGNING_KEY"
+
+	// envVarPublicKey holds the matching SSH public key, embedded into
+	// the release binaries so "recur verify-release" can check the
+	// signature without the user having to supply a key of their own.
+	envVarPublicKey = "RECUR_RELEASE_PUBLIC_KEY"
+
+	signatureNamespace = "recur-release"
 )
 
 type BuildTarget struct {
@@ -51,6 +62,13 @@ func main() {
 			os.Exit(1)
 		}
 	}
+
+	if signingKey := os.Getenv(envVarSigningKey); signingKey != "" {
+		if err := signChecksums(releaseDir, signingKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to sign checksums: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
 func build(dir string, target BuildTarget, version string) error {
@@ -73,7 +91,18 @@ func build(dir string, target BuildTarget, version string) error {
 	filename := fmt.Sprintf("%s-v%s-%s-%s%s", projectName, version, target.os, arch, ext)
 	outputPath := filepath.Join(dir, filename)
 
-	cmd := exec.Command("go", "build", "-trimpath", "-o", outputPath, ".")
+	ldflags := ""
+	if publicKey := os.Getenv(envVarPublicKey); publicKey != "" {
+		ldflags = "-X 'main.embeddedPublicKey=" + publicKey + "'"
+	}
+
+	args := []string{"build", "-trimpath"}
+	if ldflags != "" {
+		args = append(args, "-ldflags", ldflags)
+	}
+	args = append(args, "-o", outputPath, ".")
+
+	cmd := exec.Command("go", args...)
 	cmd.Env = append(os.Environ(),
 		"GOOS="+target.os,
 		"GOARCH="+target.arch,
@@ -114,5 +143,20 @@ func generateChecksum(filePath, version string) error {
 		return fmt.Errorf("Failed to write checksum: %v", err)
 	}
 
+	return nil
+}
+
+// signChecksums produces an SSH-key signature over SHA512SUMS.txt using
+// "ssh-keygen -Y sign", so downloads can be verified without a separate
+// signing tool. The signature is written alongside the checksum file as
+// SHA512SUMS.txt.sig.
+func signChecksums(dir, signingKey string) error {
+	checksumFilePath := filepath.Join(dir, checksumFilename)
+
+	cmd := exec.Command("ssh-keygen", "-Y", "sign", "-f", signingKey, "-n", signatureNamespace, checksumFilePath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ssh-keygen -Y sign failed: %v\nOutput:\n%s", err, output)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,189 @@
+// Copyright (c) 2023-2025 D. Bohdan
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// embeddedPublicKey is baked in at build time via
+// "-ldflags -X main.embeddedPublicKey=...". It's the SSH public key that
+// matches the private key script/release.go signs SHA512SUMS.txt with.
+var embeddedPublicKey string
+
+const releaseSignatureNamespace = "recur-release"
+
+// runVerifyRelease implements the "recur verify-release" subcommand.
+func runVerifyRelease(args []string) int {
+	var tarball, sumsFile, sigFile string
+
+	i := 0
+	for ; i < len(args); i++ {
+		switch args[i] {
+		case "--sums":
+			i++
+
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: no value for option: --sums")
+
+				return exitCodeBadUsage
+			}
+
+			sumsFile = args[i]
+
+		case "--sig":
+			i++
+
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: no value for option: --sig")
+
+				return exitCodeBadUsage
+			}
+
+			sigFile = args[i]
+
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(os.Stderr, "Error: unknown option: %v\n", args[i])
+
+				return exitCodeBadUsage
+			}
+
+			tarball = args[i]
+		}
+	}
+
+	if tarball == "" {
+		fmt.Fprintln(os.Stderr, "Error: verify-release requires a <tarball> argument")
+
+		return exitCodeBadUsage
+	}
+
+	if sumsFile == "" {
+		sumsFile = filepath.Join(filepath.Dir(tarball), "SHA512SUMS.txt")
+	}
+
+	if sigFile == "" {
+		sigFile = sumsFile + ".sig"
+	}
+
+	if err := verifyChecksum(tarball, sumsFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		return exitCodeError
+	}
+
+	if err := verifySumsSignature(sumsFile, sigFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		return exitCodeError
+	}
+
+	fmt.Printf("OK: %s matches %s and %s is signed by the recur release key\n", filepath.Base(tarball), filepath.Base(sumsFile), filepath.Base(sumsFile))
+
+	return 0
+}
+
+// verifyChecksum checks that tarball's SHA-512 matches its entry in
+// sumsFile, the same format script/release.go's generateChecksum writes.
+func verifyChecksum(tarball, sumsFile string) error {
+	data, err := os.ReadFile(sumsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum file: %w", err)
+	}
+
+	base := filepath.Base(tarball)
+
+	var wantHash string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == base {
+			wantHash = fields[0]
+
+			break
+		}
+	}
+
+	if wantHash == "" {
+		return fmt.Errorf("no checksum entry for %s in %s", base, sumsFile)
+	}
+
+	f, err := os.Open(tarball)
+	if err != nil {
+		return fmt.Errorf("failed to open tarball: %w", err)
+	}
+	defer f.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash tarball: %w", err)
+	}
+
+	gotHash := hex.EncodeToString(h.Sum(nil))
+	if gotHash != wantHash {
+		return fmt.Errorf("checksum mismatch for %s: want %s, got %s", base, wantHash, gotHash)
+	}
+
+	return nil
+}
+
+// verifySumsSignature checks sigFile's signature over sumsFile against
+// the public key embedded in this binary, using "ssh-keygen -Y verify".
+func verifySumsSignature(sumsFile, sigFile string) error {
+	if embeddedPublicKey == "" {
+		return fmt.Errorf("this binary wasn't built with an embedded release public key")
+	}
+
+	tempDir, err := os.MkdirTemp("", "recur-verify-release")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	allowedSigners := filepath.Join(tempDir, "allowed_signers")
+	line := fmt.Sprintf("release %s\n", embeddedPublicKey)
+
+	if err := os.WriteFile(allowedSigners, []byte(line), 0o600); err != nil {
+		return fmt.Errorf("failed to write allowed_signers file: %w", err)
+	}
+
+	sums, err := os.Open(sumsFile)
+	if err != nil {
+		return fmt.Errorf("failed to open checksum file: %w", err)
+	}
+	defer sums.Close()
+
+	cmd := exec.Command("ssh-keygen", "-Y", "verify", "-f", allowedSigners, "-I", "release", "-n", releaseSignatureNamespace, "-s", sigFile)
+	cmd.Stdin = sums
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("signature verification failed: %w\n%s", err, output)
+	}
+
+	return nil
+}